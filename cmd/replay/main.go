@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/cmd/replay/options"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/audit"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/conf"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/spf13/pflag"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/types"
+	cliflag "k8s.io/component-base/cli/flag"
+	"os"
+	k8syaml "sigs.k8s.io/yaml"
+
+	// Import default policies.
+	_ "github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies"
+)
+
+func main() {
+	o := options.NewOption()
+	o.AddFlags(pflag.CommandLine)
+
+	cliflag.InitFlags()
+	if err := o.CheckOptionOrDie(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if err := Run(o); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Run replays a cycle recorded in the audit log with the recorded policies or the given one, and writes
+// the allocations and where they differ from the recorded ones
+func Run(opt *options.Option) error {
+	record, err := audit.FindRecord(opt.FileIn, types.UID(opt.Session))
+	if err != nil {
+		return err
+	}
+
+	policyOptions := record.Policies
+	if opt.Policy != "" {
+		policyOptions = []conf.PolicyOption{
+			{
+				Name:          opt.Policy,
+				Configuration: conf.Configuration{Arguments: opt.Arguments},
+			},
+		}
+	}
+	policies, _, err := session.BuildPolicies(policyOptions)
+	if err != nil {
+		return err
+	}
+
+	result, err := audit.Replay(record, policies)
+	if err != nil {
+		return err
+	}
+	out, err := k8syaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if opt.FileOut == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(opt.FileOut, out, 0644)
+}
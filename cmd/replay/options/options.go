@@ -0,0 +1,37 @@
+package options
+
+import (
+	"fmt"
+	"github.com/spf13/pflag"
+)
+
+type Option struct {
+	FileIn    string
+	Session   string
+	Policy    string
+	Arguments map[string]string
+	FileOut   string
+}
+
+func NewOption() *Option {
+	o := Option{}
+	return &o
+}
+
+func (o *Option) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.FileIn, "file", "", "Path to the audit log of the scheduler")
+	fs.StringVar(&o.Session, "session", "", "The session of the recorded cycle to replay; the latest cycle if not specified")
+	fs.StringVar(&o.Policy, "policy", "", "The policy to replay the cycle with; the recorded policies if not specified")
+	fs.StringToStringVar(&o.Arguments, "policy-arguments", nil, "The arguments of the policy to replay the cycle with, e.g. key1=value1,key2=value2")
+	fs.StringVar(&o.FileOut, "out", "", "Path to the replay result file; stdout if not specified")
+}
+
+func (o *Option) CheckOptionOrDie() error {
+	if o.FileIn == "" {
+		return fmt.Errorf("audit log must be specified")
+	}
+	if o.Policy == "" && len(o.Arguments) > 0 {
+		return fmt.Errorf("policy arguments require a policy")
+	}
+	return nil
+}
@@ -27,10 +27,16 @@ import (
 
 const (
 	defaultSchedulerName   = "volcano"
-	defaultSchedulerPeriod = time.Second
+	defaultSchedulerPeriod = 10 * time.Second
 	defaultQueue           = "default"
 	defaultListenAddress   = ":8080"
 
+	defaultScheduleDebounce    = 100 * time.Millisecond
+	defaultScheduleMinInterval = time.Second
+
+	defaultAuditLogMaxSize    = 100
+	defaultAuditLogMaxBackups = 3
+
 	defaultQPS   = 50.0
 	defaultBurst = 100
 
@@ -46,12 +52,22 @@ type ServerOption struct {
 	SchedulerName        string
 	SchedulerConf        string
 	SchedulePeriod       time.Duration
+	ScheduleDebounce     time.Duration
+	ScheduleMinInterval  time.Duration
+	StateConfigMap       string
 	EnableLeaderElection bool
 	LockObjectNamespace  string
 	DefaultQueue         string
 	PrintVersion         bool
 	ListenAddress        string
 	EnablePriorityClass  bool
+	DryRun               bool
+	DryRunFile           string
+
+	// AuditLog is the file the record of each scheduling cycle is appended to, none if empty
+	AuditLog           string
+	AuditLogMaxSize    int
+	AuditLogMaxBackups int
 
 	// Parameters for scheduling tuning: the number of feasible nodes to find and score
 	MinNodesToFind             int32
@@ -75,7 +91,10 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	// volcano scheduler will ignore pods with scheduler names other than specified with the option
 	fs.StringVar(&s.SchedulerName, "scheduler-name", defaultSchedulerName, "vc-scheduler will handle pods whose .spec.SchedulerName is same as scheduler-name")
 	fs.StringVar(&s.SchedulerConf, "scheduler-conf", "", "The absolute path of scheduler configuration file")
-	fs.DurationVar(&s.SchedulePeriod, "schedule-period", defaultSchedulerPeriod, "The period between each scheduling cycle")
+	fs.DurationVar(&s.SchedulePeriod, "schedule-period", defaultSchedulerPeriod, "The max period between scheduling cycles. Cycles run when jobs, nodes or job progress change, so the period only bounds how late time-based decisions, such as time slices, are made")
+	fs.DurationVar(&s.ScheduleDebounce, "schedule-debounce", defaultScheduleDebounce, "The time changes must settle for before a scheduling cycle runs, delaying it by at most schedule-min-interval plus the debounce")
+	fs.DurationVar(&s.ScheduleMinInterval, "schedule-min-interval", defaultScheduleMinInterval, "The min period between scheduling cycles")
+	fs.StringVar(&s.StateConfigMap, "state-configmap", "", "The ConfigMap (namespace/name) to persist the state policies keep for each job; kept in memory only if empty")
 	fs.StringVar(&s.DefaultQueue, "default-queue", defaultQueue, "The default queue name of the job")
 	fs.BoolVar(&s.EnableLeaderElection, "leader-elect", s.EnableLeaderElection,
 		"Start a leader election client and gain leadership before "+
@@ -84,7 +103,14 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.LockObjectNamespace, "lock-object-namespace", s.LockObjectNamespace, "Define the namespace of the lock object that is used for leader election")
 	fs.StringVar(&s.ListenAddress, "listen-address", defaultListenAddress, "The address to listen on for HTTP requests.")
 	fs.BoolVar(&s.EnablePriorityClass, "priority-class", true,
-		"Enable PriorityClass to provide the capacity of preemption at pod group level; to disable it, set it false")
+		"Enable PriorityClass to resolve the priorities of PintaJobs, which preempt jobs of lower priority; to disable it, set it false")
+	fs.BoolVar(&s.DryRun, "dry-run", false,
+		"Compute allocations without committing them; the proposed changes are logged and served at /dry-run. Leader election is skipped")
+	fs.StringVar(&s.DryRunFile, "dry-run-file", "", "The file the proposed changes of each dry-run cycle are appended to as JSON lines")
+	fs.StringVar(&s.AuditLog, "audit-log", "",
+		"The file the snapshot, policies and allocations of each scheduling cycle are appended to as JSON lines, to be replayed offline")
+	fs.IntVar(&s.AuditLogMaxSize, "audit-log-max-size", defaultAuditLogMaxSize, "The size in MiB the audit log is rotated at; 0 never rotates it")
+	fs.IntVar(&s.AuditLogMaxBackups, "audit-log-max-backups", defaultAuditLogMaxBackups, "The number of rotated audit log files to keep")
 	fs.Float32Var(&s.KubeClientOptions.QPS, "kube-api-qps", defaultQPS, "QPS to use while talking with kubernetes apiserver")
 	fs.IntVar(&s.KubeClientOptions.Burst, "kube-api-burst", defaultBurst, "Burst to use while talking with kubernetes apiserver")
 
@@ -103,6 +129,15 @@ func (s *ServerOption) CheckOptionOrDie() error {
 	if s.EnableLeaderElection && s.LockObjectNamespace == "" {
 		return fmt.Errorf("lock-object-namespace must not be nil when LeaderElection is enabled")
 	}
+	if s.SchedulePeriod <= 0 {
+		return fmt.Errorf("schedule-period must be positive")
+	}
+	if s.ScheduleDebounce < 0 || s.ScheduleMinInterval < 0 {
+		return fmt.Errorf("schedule-debounce and schedule-min-interval must not be negative")
+	}
+	if s.AuditLogMaxSize < 0 || s.AuditLogMaxBackups < 0 {
+		return fmt.Errorf("audit-log-max-size and audit-log-max-backups must not be negative")
+	}
 
 	return nil
 }
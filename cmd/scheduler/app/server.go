@@ -19,6 +19,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 	"volcano.sh/volcano/pkg/version"
@@ -48,8 +49,8 @@ const (
 	retryPeriod   = 5 * time.Second
 )
 
-// Run the volcano scheduler.
-func Run(opt *options.ServerOption) error {
+// Run the volcano scheduler. The endpoints of the scheduler are registered to mux.
+func Run(opt *options.ServerOption, mux *http.ServeMux) error {
 	if opt.PrintVersion {
 		version.PrintVersionAndExit()
 	}
@@ -61,17 +62,34 @@ func Run(opt *options.ServerOption) error {
 
 	sched, err := scheduler.NewScheduler(config,
 		opt.SchedulerConf,
-		opt.SchedulePeriod)
+		opt.SchedulePeriod,
+		opt.ScheduleDebounce,
+		opt.ScheduleMinInterval,
+		opt.StateConfigMap,
+		opt.EnablePriorityClass)
 	if err != nil {
 		panic(err)
 	}
+	mux.Handle(scheduler.ProgressPath, sched.ProgressHandler())
+	if opt.DryRun {
+		if err := sched.EnableDryRun(opt.DryRunFile); err != nil {
+			return err
+		}
+		mux.Handle(scheduler.DryRunPath, sched.DryRunHandler())
+	}
+	if opt.AuditLog != "" {
+		if err := sched.EnableAuditLog(opt.AuditLog, int64(opt.AuditLogMaxSize)<<20, opt.AuditLogMaxBackups); err != nil {
+			return err
+		}
+	}
 
 	run := func(ctx context.Context) {
 		sched.Run(ctx.Done())
 		<-ctx.Done()
 	}
 
-	if !opt.EnableLeaderElection {
+	// A scheduler in dry-run mode commits nothing, so it runs alongside the leader
+	if !opt.EnableLeaderElection || opt.DryRun {
 		run(context.TODO())
 		return fmt.Errorf("finished without leader elect")
 	}
@@ -60,10 +60,11 @@ func main() {
 	go wait.Until(klog.Flush, *logFlushFreq, wait.NeverStop)
 	defer klog.Flush()
 
-	// start serving prometheus on 8080
+	// start serving prometheus and progress reports of jobs on 8080
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/metrics", promhttp.Handler())
 	metrics.RegisterPintaJob()
+	metrics.RegisterScheduler()
 	go func() {
 		klog.Info("Listening and serving metrics at port 8080...")
 		err := http.ListenAndServe(":8080", metricsMux)
@@ -72,7 +73,7 @@ func main() {
 		}
 	}()
 
-	if err := app.Run(s); err != nil {
+	if err := app.Run(s, metricsMux); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
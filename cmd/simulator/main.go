@@ -8,6 +8,9 @@ import (
 	"github.com/spf13/pflag"
 	cliflag "k8s.io/component-base/cli/flag"
 	"os"
+
+	// Import default policies.
+	_ "github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies"
 )
 
 func main() {
@@ -27,6 +30,14 @@ func main() {
 }
 
 func Run(opt *options.Option) error {
+	if opt.Offline {
+		sim, err := simulator.NewOfflineSimulator(opt)
+		if err != nil {
+			return err
+		}
+		return sim.Run()
+	}
+
 	config, err := kube.BuildConfig(opt.KubeClientOptions)
 	if err != nil {
 		return err
@@ -9,6 +9,14 @@ import (
 type Option struct {
 	KubeClientOptions kube.ClientOptions
 	FileIn            string
+
+	// Offline simulation
+	Offline     bool
+	ClusterFile string
+	Policy      string
+	Arguments   map[string]string
+	Period      float64
+	FileOut     string
 }
 
 func NewOption() *Option {
@@ -20,11 +28,23 @@ func (o *Option) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.KubeClientOptions.Master, "master", o.KubeClientOptions.Master, "The address of the Kubernetes API server (overrides any value in kubeconfig)")
 	fs.StringVar(&o.KubeClientOptions.KubeConfig, "kubeconfig", o.KubeClientOptions.KubeConfig, "Path to kubeconfig file with authorization and master location information")
 	fs.StringVar(&o.FileIn, "file", "", "Path to jobs specification file")
+	fs.BoolVar(&o.Offline, "offline", false, "Simulate the policy against a synthetic cluster with a virtual clock instead of creating jobs in Kubernetes")
+	fs.StringVar(&o.ClusterFile, "cluster", "", "Path to the synthetic cluster specification file, used in offline mode")
+	fs.StringVar(&o.Policy, "policy", "hell", "The policy to simulate in offline mode")
+	fs.StringToStringVar(&o.Arguments, "policy-arguments", nil, "The arguments of the policy to simulate in offline mode, e.g. key1=value1,key2=value2")
+	fs.Float64Var(&o.Period, "period", 0, "The period in virtual seconds between scheduling cycles in offline mode, in addition to cycles on job arrival and completion; 0 disables periodic cycles")
+	fs.StringVar(&o.FileOut, "out", "", "Path to the simulation result file in offline mode; stdout if not specified")
 }
 
 func (o *Option) CheckOptionOrDie() error {
 	if o.FileIn == "" {
 		return fmt.Errorf("jobs file must be specified")
 	}
+	if o.Offline && o.ClusterFile == "" {
+		return fmt.Errorf("cluster file must be specified in offline mode")
+	}
+	if o.Period < 0 {
+		return fmt.Errorf("period must not be negative")
+	}
 	return nil
 }
@@ -5,6 +5,7 @@ go 1.14
 require (
 	github.com/prometheus/client_golang v1.0.0
 	github.com/spf13/pflag v1.0.5
+	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4
 	gopkg.in/yaml.v2 v2.2.8
 	k8s.io/api v0.18.5
 	k8s.io/apimachinery v0.18.5
@@ -1,13 +1,30 @@
 package info
 
 type ClusterInfo struct {
-	Jobs  map[JobID]*JobInfo
-	Nodes map[string]*NodeInfo
+	Jobs   map[JobID]*JobInfo
+	Nodes  map[string]*NodeInfo
+	Queues map[string]*QueueInfo
 }
 
 func NewClusterInfo() *ClusterInfo {
 	return &ClusterInfo{
-		Jobs:  make(map[JobID]*JobInfo),
-		Nodes: make(map[string]*NodeInfo),
+		Jobs:   make(map[JobID]*JobInfo),
+		Nodes:  make(map[string]*NodeInfo),
+		Queues: make(map[string]*QueueInfo),
 	}
 }
+
+// Clone returns a deep copy of the cluster
+func (ci *ClusterInfo) Clone() *ClusterInfo {
+	clone := NewClusterInfo()
+	for id, job := range ci.Jobs {
+		clone.Jobs[id] = job.Clone()
+	}
+	for name, node := range ci.Nodes {
+		clone.Nodes[name] = node.Clone()
+	}
+	for name, queue := range ci.Queues {
+		clone.Queues[name] = queue.Clone()
+	}
+	return clone
+}
@@ -3,13 +3,26 @@ package info
 import (
 	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
 	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"reflect"
 )
 
+// PreemptibleAnnotationKey is the annotation of a PriorityClass that is set to "false" if the jobs of
+// the class must not be preempted
+const PreemptibleAnnotationKey = "pinta.qed.usc.edu/preemptible"
+
 type JobID types.UID
 
+// JobProgress is the progress a job reports to the scheduler on its own
+type JobProgress struct {
+	CompletedIterations int `json:"completedIterations"`
+	// ReportTime is when the progress is received
+	ReportTime metav1.Time `json:"reportTime,omitempty"`
+}
+
 type JobInfo struct {
 	UID       JobID
 	Name      string
@@ -19,9 +32,37 @@ type JobInfo struct {
 	NumMasters  int32
 	NumReplicas int32
 
+	// Bounds of the # masters and replicas the job may run with, where unbounded maximums are
+	// math.MaxInt32
+	MinMasters  int32
+	MaxMasters  int32
+	MinReplicas int32
+	MaxReplicas int32
+
+	// Node types masters and replicas are pinned to; empty if any node type can be used
+	MasterNodeType  string
+	ReplicaNodeType string
+
+	// Resources of each master and replica, as specified in the PintaJob
+	MasterResources  v1.ResourceList
+	ReplicaResources v1.ResourceList
+
+	// Queue is the name of the PintaQueue the job is submitted to, empty if none
+	Queue string
+
+	// Priority of the job resolved from its PriorityClass; jobs of higher priority may preempt running
+	// jobs of lower priority that are preemptible
+	Priority    int32
+	Preemptible bool
+
 	CreationTimestamp metav1.Time
 
+	// Progress is the latest progress reported by the job, nil if it has not reported any
+	Progress *JobProgress
+
 	CustomFields interface{}
+	// State is the state the executing policy keeps for the job between sessions
+	State interface{}
 
 	Job *pintav1.PintaJob
 }
@@ -31,6 +72,7 @@ func NewJobInfo(uid JobID, job *pintav1.PintaJob) *JobInfo {
 	if len(job.Status) > 0 {
 		lastPintaJobStatus = job.Status[0]
 	}
+	minMasters, maxMasters, minReplicas, maxReplicas := job.Spec.Bounds()
 	jobInfo := &JobInfo{
 		UID:       uid,
 		Name:      job.Name,
@@ -40,6 +82,21 @@ func NewJobInfo(uid JobID, job *pintav1.PintaJob) *JobInfo {
 		NumMasters:  lastPintaJobStatus.NumMasters,
 		NumReplicas: lastPintaJobStatus.NumReplicas,
 
+		MinMasters:  minMasters,
+		MaxMasters:  maxMasters,
+		MinReplicas: minReplicas,
+		MaxReplicas: maxReplicas,
+
+		MasterNodeType:  job.Spec.Master.NodeType,
+		ReplicaNodeType: job.Spec.Replica.NodeType,
+
+		MasterResources:  job.Spec.Master.Resources,
+		ReplicaResources: job.Spec.Replica.Resources,
+
+		Queue: job.Spec.Queue,
+
+		Preemptible: true,
+
 		CreationTimestamp: job.GetCreationTimestamp(),
 
 		Job: job,
@@ -58,6 +115,65 @@ func (ji *JobInfo) ParseCustomFields(customFieldsType reflect.Type) error {
 	return nil
 }
 
+// CompletedIterations returns the # iterations the job has reported to have completed
+func (ji *JobInfo) CompletedIterations() int {
+	if ji.Progress == nil {
+		return 0
+	}
+	return ji.Progress.CompletedIterations
+}
+
+// RunningSince returns when the job started running with nodes allocated, and whether it is running at all.
+// Resizing a running job does not restart the clock.
+func (ji *JobInfo) RunningSince() (metav1.Time, bool) {
+	var since metav1.Time
+	running := false
+	for _, status := range ji.Job.Status {
+		if status.NumMasters+status.NumReplicas == 0 {
+			break
+		}
+		since = status.LastTransitionTime
+		running = true
+	}
+	return since, running
+}
+
+// FitMasters returns the # masters within the bounds of the job that is closest to n
+func (ji *JobInfo) FitMasters(n int32) int32 {
+	return fit(n, ji.MinMasters, ji.MaxMasters)
+}
+
+// FitReplicas returns the # replicas within the bounds of the job that is closest to n
+func (ji *JobInfo) FitReplicas(n int32) int32 {
+	return fit(n, ji.MinReplicas, ji.MaxReplicas)
+}
+
+// InBounds returns whether the job may run with the # masters and replicas. A job may always be
+// stopped.
+func (ji *JobInfo) InBounds(numMasters, numReplicas int32) bool {
+	if numMasters == 0 && numReplicas == 0 {
+		return true
+	}
+	return fit(numMasters, ji.MinMasters, ji.MaxMasters) == numMasters &&
+		fit(numReplicas, ji.MinReplicas, ji.MaxReplicas) == numReplicas
+}
+
+func fit(n, min, max int32) int32 {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// SetPriorityClass sets the priority of the job to the one of the PriorityClass
+func (ji *JobInfo) SetPriorityClass(priorityClass *schedulingv1.PriorityClass) {
+	ji.Priority = priorityClass.Value
+	ji.Preemptible = priorityClass.Annotations[PreemptibleAnnotationKey] != "false"
+}
+
 func (ji *JobInfo) Clone() *JobInfo {
 	info := &JobInfo{
 		UID:          ji.UID,
@@ -67,10 +183,31 @@ func (ji *JobInfo) Clone() *JobInfo {
 		NumMasters:   ji.NumMasters,
 		NumReplicas:  ji.NumReplicas,
 		CustomFields: ji.CustomFields,
+		State:        ji.State,
 		Job:          ji.Job.DeepCopy(),
+
+		MinMasters:  ji.MinMasters,
+		MaxMasters:  ji.MaxMasters,
+		MinReplicas: ji.MinReplicas,
+		MaxReplicas: ji.MaxReplicas,
+
+		MasterNodeType:  ji.MasterNodeType,
+		ReplicaNodeType: ji.ReplicaNodeType,
+
+		MasterResources:  ji.MasterResources,
+		ReplicaResources: ji.ReplicaResources,
+
+		Queue: ji.Queue,
+
+		Priority:    ji.Priority,
+		Preemptible: ji.Preemptible,
 	}
 
 	ji.CreationTimestamp.DeepCopyInto(&info.CreationTimestamp)
+	if ji.Progress != nil {
+		progress := *ji.Progress
+		info.Progress = &progress
+	}
 
 	return info
 }
@@ -4,6 +4,7 @@ import (
 	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -71,6 +72,9 @@ func TestNewJobInfo(t *testing.T) {
 				Type:              "type1",
 				NumMasters:        1,
 				NumReplicas:       2,
+				MinReplicas:       1,
+				MaxReplicas:       math.MaxInt32,
+				Preemptible:       true,
 				CreationTimestamp: ts,
 				CustomFields:      nil,
 				Job:               t1job,
@@ -123,6 +127,9 @@ s: test
 				Type:              "type1",
 				NumMasters:        1,
 				NumReplicas:       2,
+				MinReplicas:       1,
+				MaxReplicas:       math.MaxInt32,
+				Preemptible:       true,
 				CreationTimestamp: ts,
 				CustomFields:      &customFields,
 				Job:               t1job,
@@ -143,3 +150,78 @@ s: test
 		}
 	}
 }
+
+func TestJobInfo_RunningSince(t *testing.T) {
+	t1 := metav1.NewTime(time.Unix(100, 0))
+	t2 := metav1.NewTime(time.Unix(200, 0))
+	t3 := metav1.NewTime(time.Unix(300, 0))
+
+	tests := []struct {
+		status          []pintav1.PintaJobStatus
+		expectedSince   metav1.Time
+		expectedRunning bool
+	}{
+		{
+			status: []pintav1.PintaJobStatus{
+				{LastTransitionTime: t1},
+			},
+			expectedRunning: false,
+		},
+		{
+			status: []pintav1.PintaJobStatus{
+				{LastTransitionTime: t3, NumReplicas: 2},
+				{LastTransitionTime: t2, NumReplicas: 1},
+				{LastTransitionTime: t1},
+			},
+			expectedSince:   t2,
+			expectedRunning: true,
+		},
+		{
+			status: []pintav1.PintaJobStatus{
+				{LastTransitionTime: t3},
+				{LastTransitionTime: t2, NumReplicas: 1},
+			},
+			expectedRunning: false,
+		},
+	}
+
+	for i, test := range tests {
+		job := buildPintaJob("j1", t1)
+		job.Status = test.status
+		since, running := NewJobInfo("j1", job).RunningSince()
+		if since != test.expectedSince || running != test.expectedRunning {
+			t.Errorf("job info %d: expected %v %v, got %v %v",
+				i, test.expectedSince, test.expectedRunning, since, running)
+		}
+	}
+}
+
+func TestJobInfo_InBounds(t *testing.T) {
+	job := buildPintaJob("j1", metav1.Now())
+	job.Spec.Type = pintav1.PSWorker
+	job.Spec.MinReplicas = 2
+	job.Spec.MaxReplicas = 4
+	ji := NewJobInfo("j1", job)
+
+	tests := []struct {
+		numMasters  int32
+		numReplicas int32
+		expected    bool
+	}{
+		{0, 0, true},
+		{1, 2, true},
+		{3, 4, true},
+		{0, 2, false},
+		{1, 1, false},
+		{1, 5, false},
+	}
+	for _, test := range tests {
+		if inBounds := ji.InBounds(test.numMasters, test.numReplicas); inBounds != test.expected {
+			t.Errorf("%d masters and %d replicas: expected in bounds %v, got %v",
+				test.numMasters, test.numReplicas, test.expected, inBounds)
+		}
+	}
+	if n := ji.FitReplicas(8); n != 4 {
+		t.Errorf("Expected 8 replicas to fit as 4, got %d", n)
+	}
+}
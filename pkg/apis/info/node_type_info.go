@@ -18,3 +18,24 @@ func (nti *NodeTypeInfo) AddNode(ni *NodeInfo) {
 	nti.Resource.SetMinResource(ni.Allocatable)
 	nti.Nodes = append(nti.Nodes, ni)
 }
+
+// NodeTypeResource returns the resources of one node of nodeType. Roles without a node type refer to
+// unlabeled nodes, or to the resources shared by all node types if every node is labeled.
+func NodeTypeResource(nodeTypes map[string]*NodeTypeInfo, nodeType string) (*Resource, bool) {
+	if nodeTypeInfo, found := nodeTypes[nodeType]; found {
+		return nodeTypeInfo.Resource, true
+	}
+	if nodeType != "" || len(nodeTypes) == 0 {
+		return nil, false
+	}
+
+	var shared *Resource
+	for _, nodeTypeInfo := range nodeTypes {
+		if shared == nil {
+			shared = nodeTypeInfo.Resource.Clone()
+		} else {
+			shared.SetMinResource(nodeTypeInfo.Resource)
+		}
+	}
+	return shared, true
+}
@@ -0,0 +1,48 @@
+package info
+
+import (
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+type QueueInfo struct {
+	Name   string
+	Parent string
+	Weight int32
+
+	// Resources the queue is entitled to, and may borrow beyond that; a nil BorrowingLimit is unlimited
+	Guarantee      v1.ResourceList
+	BorrowingLimit v1.ResourceList
+
+	// MaxRunningJobs is the max # jobs of the queue that run at once, 0 if unlimited
+	MaxRunningJobs int32
+
+	Queue *pintav1.PintaQueue
+}
+
+func NewQueueInfo(queue *pintav1.PintaQueue) *QueueInfo {
+	weight := queue.Spec.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	borrowingLimit := queue.Spec.BorrowingLimit
+	if len(borrowingLimit) == 0 {
+		borrowingLimit = nil
+	}
+	return &QueueInfo{
+		Name:   queue.Name,
+		Parent: queue.Spec.Parent,
+		Weight: weight,
+
+		Guarantee:      queue.Spec.Guarantee,
+		BorrowingLimit: borrowingLimit,
+
+		MaxRunningJobs: queue.Spec.MaxRunningJobs,
+
+		Queue: queue,
+	}
+}
+
+func (qi *QueueInfo) Clone() *QueueInfo {
+	return NewQueueInfo(qi.Queue.DeepCopy())
+}
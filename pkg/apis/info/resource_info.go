@@ -24,6 +24,8 @@ type Resource struct {
 const (
 	// GPUResourceName need to follow https://github.com/NVIDIA/k8s-device-plugin/blob/66a35b71ac4b5cbfb04714678b548bd77e5ba719/server.go#L20
 	GPUResourceName = "nvidia.com/gpu"
+	// NodeResourceName is the resource of PintaJob roles that is measured in nodes of the role's node type
+	NodeResourceName v1.ResourceName = "node"
 )
 
 // EmptyResource creates a empty resource object and returns
@@ -74,6 +76,44 @@ func NewResource(rl v1.ResourceList) *Resource {
 	return r
 }
 
+// NewRoleResource creates a new resource object from the resources of a PintaJob role. A "node" resource
+// is translated to that many times the resources of one node of nodeType, and cannot be specified
+// together with other resource types.
+func NewRoleResource(rl v1.ResourceList, nodeTypes map[string]*NodeTypeInfo, nodeType string) (*Resource, error) {
+	fractionNode, found := rl[NodeResourceName]
+	if !found || fractionNode.IsZero() {
+		return NewResource(rl), nil
+	}
+	if fractionNode.Sign() < 0 {
+		return nil, fmt.Errorf("resources.node must not be negative")
+	}
+	if len(rl) != 1 {
+		return nil, fmt.Errorf("resources.node cannot be specified together with other resource types")
+	}
+
+	nodeResource, found := NodeTypeResource(nodeTypes, nodeType)
+	if !found {
+		return nil, fmt.Errorf("node type %v does not exist", nodeType)
+	}
+	return nodeResource.Clone().Multi(float64(fractionNode.MilliValue()) / 1000), nil
+}
+
+// SplitRoleResources returns the resources of each pod of a PintaJob role and the # pods that each master
+// or replica runs as. Masters and replicas of more than one node run as one pod per node, so they must
+// take a whole number of nodes.
+func SplitRoleResources(rl v1.ResourceList) (v1.ResourceList, int32, error) {
+	numNodes, found := rl[NodeResourceName]
+	if !found || numNodes.MilliValue() <= 1000 {
+		return rl, 1, nil
+	}
+	if numNodes.MilliValue()%1000 != 0 {
+		return nil, 0, fmt.Errorf("resources.node must be a whole number of nodes if greater than 1")
+	}
+	podResources := rl.DeepCopy()
+	podResources[NodeResourceName] = *resource.NewQuantity(1, resource.DecimalSI)
+	return podResources, int32(numNodes.Value()), nil
+}
+
 // IsEmpty returns bool after checking any of resource is less than min possible value
 func (r *Resource) IsEmpty() bool {
 	if !(r.MilliCPU < minMilliCPU && r.Memory < minMemory) {
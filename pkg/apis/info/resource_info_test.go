@@ -583,3 +583,139 @@ func TestResource_ToResourceList(t *testing.T) {
 		}
 	}
 }
+
+func TestNewRoleResource(t *testing.T) {
+	nodeTypes := map[string]*NodeTypeInfo{
+		"cpu": {Resource: buildResource("4", "8Gi")},
+		"gpu": {Resource: &Resource{MilliCPU: 8000, Memory: 4 * 1024 * 1024 * 1024,
+			ScalarResources: map[v1.ResourceName]float64{GPUResourceName: 2000}}},
+	}
+
+	tests := []struct {
+		name         string
+		resourceList v1.ResourceList
+		nodeType     string
+		expected     *Resource
+		expectError  bool
+	}{
+		{
+			name:         "plain resources",
+			resourceList: buildResourceList("1", "1Gi"),
+			nodeType:     "cpu",
+			expected:     buildResource("1", "1Gi"),
+		},
+		{
+			name:         "half a node",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("0.5")},
+			nodeType:     "cpu",
+			expected:     buildResource("2", "4Gi"),
+		},
+		{
+			name:         "two nodes",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("2")},
+			nodeType:     "cpu",
+			expected:     buildResource("8", "16Gi"),
+		},
+		{
+			name:         "resources shared by all node types",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("1")},
+			nodeType:     "",
+			expected:     buildResource("4", "4Gi"),
+		},
+		{
+			name: "node with other resource types",
+			resourceList: v1.ResourceList{
+				NodeResourceName: resource.MustParse("1"),
+				v1.ResourceCPU:   resource.MustParse("1"),
+			},
+			nodeType:    "cpu",
+			expectError: true,
+		},
+		{
+			name:         "negative node",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("-1")},
+			nodeType:     "cpu",
+			expectError:  true,
+		},
+		{
+			name:         "unknown node type",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("1")},
+			nodeType:     "tpu",
+			expectError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		r, err := NewRoleResource(test.resourceList, nodeTypes, test.nodeType)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !r.EqualStrict(test.expected) || !test.expected.EqualStrict(r) {
+			t.Errorf("%s: expected: %v, got: %v", test.name, test.expected, r)
+		}
+	}
+}
+
+func TestSplitRoleResources(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceList v1.ResourceList
+		expected     v1.ResourceList
+		numPods      int32
+		expectError  bool
+	}{
+		{
+			name:         "resources",
+			resourceList: v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")},
+			expected:     v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")},
+			numPods:      1,
+		},
+		{
+			name:         "half a node",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("0.5")},
+			expected:     v1.ResourceList{NodeResourceName: resource.MustParse("0.5")},
+			numPods:      1,
+		},
+		{
+			name:         "two nodes",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("2")},
+			expected:     v1.ResourceList{NodeResourceName: resource.MustParse("1")},
+			numPods:      2,
+		},
+		{
+			name:         "one and a half nodes",
+			resourceList: v1.ResourceList{NodeResourceName: resource.MustParse("1.5")},
+			expectError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		rl, numPods, err := SplitRoleResources(test.resourceList)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		equal := numPods == test.numPods && len(rl) == len(test.expected)
+		for name, quantity := range test.expected {
+			if q, found := rl[name]; !found || q.Cmp(quantity) != 0 {
+				equal = false
+			}
+		}
+		if !equal {
+			t.Errorf("%s: expected %v in %d pods, got %v in %d pods", test.name, test.expected, test.numPods, rl, numPods)
+		}
+	}
+}
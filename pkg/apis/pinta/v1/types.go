@@ -38,6 +38,18 @@ type PintaJobSpec struct {
 	Volumes []volcanov1alpha1.VolumeSpec `json:"volumes,omitempty"`
 	Master  RoleSpec                     `json:"master,omitempty"`
 	Replica RoleSpec                     `json:"replica,omitempty"`
+	// MinMasters and MaxMasters bound the # masters of ps-worker and mpi jobs, and MinReplicas and
+	// MaxReplicas the # replicas of all jobs. A job never runs with fewer masters or replicas than the
+	// minimums, which default to 1, or with more than the maximums, which are unbounded if 0.
+	MinMasters  int32 `json:"minMasters,omitempty"`
+	MaxMasters  int32 `json:"maxMasters,omitempty"`
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// Queue is the name of the PintaQueue the job is submitted to, if any
+	Queue string `json:"queue,omitempty"`
+	// PriorityClassName is the name of the PriorityClass the priority of the job is resolved from. Jobs
+	// without one take the priority of the global default PriorityClass, or 0 if there is none.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 type PintaJobType string
@@ -60,6 +72,14 @@ type PintaJobStatus struct {
 	LastTransitionTime metav1.Time   `json:"lastTransitionTime,omitempty"`
 	NumMasters         int32         `json:"numMasters,omitempty"`
 	NumReplicas        int32         `json:"numReplicas,omitempty"`
+	// Reason is why the scheduler allocates the job what it does, in CamelCase, and Message the same for
+	// humans
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// EstimatedStartTime is when the scheduler expects the job to start if it waits, and
+	// EstimatedCompletionTime when it expects the job to complete if it runs; nil if unknown
+	EstimatedStartTime      *metav1.Time `json:"estimatedStartTime,omitempty"`
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
 }
 
 type PintaJobState string
@@ -82,3 +102,38 @@ type PintaJobList struct {
 
 	Items []PintaJob `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PintaQueue is a queue PintaJobs are submitted to. Queues form a hierarchy, where the jobs of a queue
+// also count towards its ancestors.
+type PintaQueue struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PintaQueueSpec `json:"spec,omitempty"`
+}
+
+type PintaQueueSpec struct {
+	// Parent is the name of the parent queue, or empty for a top-level queue
+	Parent string `json:"parent,omitempty"`
+	// Weight is the share of idle resources the queue may borrow relative to its siblings, 1 if not set
+	Weight int32 `json:"weight,omitempty"`
+	// Guarantee is the resources the queue is entitled to, which may be measured in nodes
+	Guarantee v1.ResourceList `json:"guarantee,omitempty"`
+	// BorrowingLimit is the max resources the queue may borrow beyond its guarantee, unlimited if empty
+	BorrowingLimit v1.ResourceList `json:"borrowingLimit,omitempty"`
+	// MaxRunningJobs is the max # jobs of the queue that run at once, unlimited if not set
+	MaxRunningJobs int32 `json:"maxRunningJobs,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type PintaQueueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PintaQueue `json:"items"`
+}
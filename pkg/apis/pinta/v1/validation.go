@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate returns an error if the spec is not valid for the type of the job
+func (spec *PintaJobSpec) Validate() error {
+	if spec.MinMasters < 0 || spec.MaxMasters < 0 || spec.MinReplicas < 0 || spec.MaxReplicas < 0 {
+		return fmt.Errorf("min and max # masters and replicas must not be negative")
+	}
+
+	switch spec.Type {
+	case Symmetric, ImageBuilder:
+		if spec.MinMasters != 0 || spec.MaxMasters != 0 {
+			return fmt.Errorf("%s jobs have no masters", spec.Type)
+		}
+	case MPI:
+		if spec.MinMasters > 1 || spec.MaxMasters > 1 {
+			return fmt.Errorf("%s jobs have a single master", spec.Type)
+		}
+	}
+	if spec.Type == ImageBuilder && (spec.MinReplicas > 1 || spec.MaxReplicas > 1) {
+		return fmt.Errorf("%s jobs have a single replica", spec.Type)
+	}
+
+	minMasters, maxMasters, minReplicas, maxReplicas := spec.Bounds()
+	if minMasters > maxMasters {
+		return fmt.Errorf("minMasters %d is greater than maxMasters %d", minMasters, maxMasters)
+	}
+	if minReplicas > maxReplicas {
+		return fmt.Errorf("minReplicas %d is greater than maxReplicas %d", minReplicas, maxReplicas)
+	}
+	return nil
+}
+
+// Bounds returns the min and max # masters and replicas of the job, where unbounded maximums are
+// math.MaxInt32. The spec must be valid.
+func (spec *PintaJobSpec) Bounds() (minMasters, maxMasters, minReplicas, maxReplicas int32) {
+	switch spec.Type {
+	case PSWorker:
+		minMasters, maxMasters = 1, math.MaxInt32
+	case MPI:
+		minMasters, maxMasters = 1, 1
+	}
+	if spec.MinMasters > 0 {
+		minMasters = spec.MinMasters
+	}
+	if spec.MaxMasters > 0 {
+		maxMasters = spec.MaxMasters
+	}
+
+	minReplicas, maxReplicas = 1, math.MaxInt32
+	if spec.Type == ImageBuilder {
+		maxReplicas = 1
+	}
+	if spec.MinReplicas > 0 {
+		minReplicas = spec.MinReplicas
+	}
+	if spec.MaxReplicas > 0 {
+		maxReplicas = spec.MaxReplicas
+	}
+	return
+}
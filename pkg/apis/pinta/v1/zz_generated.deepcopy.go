@@ -122,6 +122,14 @@ func (in *PintaJobSpec) DeepCopy() *PintaJobSpec {
 func (in *PintaJobStatus) DeepCopyInto(out *PintaJobStatus) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.EstimatedStartTime != nil {
+		in, out := &in.EstimatedStartTime, &out.EstimatedStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -135,6 +143,96 @@ func (in *PintaJobStatus) DeepCopy() *PintaJobStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PintaQueue) DeepCopyInto(out *PintaQueue) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PintaQueue.
+func (in *PintaQueue) DeepCopy() *PintaQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(PintaQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PintaQueue) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PintaQueueList) DeepCopyInto(out *PintaQueueList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PintaQueue, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PintaQueueList.
+func (in *PintaQueueList) DeepCopy() *PintaQueueList {
+	if in == nil {
+		return nil
+	}
+	out := new(PintaQueueList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PintaQueueList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PintaQueueSpec) DeepCopyInto(out *PintaQueueSpec) {
+	*out = *in
+	if in.Guarantee != nil {
+		in, out := &in.Guarantee, &out.Guarantee
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.BorrowingLimit != nil {
+		in, out := &in.BorrowingLimit, &out.BorrowingLimit
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PintaQueueSpec.
+func (in *PintaQueueSpec) DeepCopy() *PintaQueueSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PintaQueueSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoleSpec) DeepCopyInto(out *RoleSpec) {
 	*out = *in
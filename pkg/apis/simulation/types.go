@@ -1,6 +1,9 @@
 package api
 
-import v1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+import (
+	v1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	corev1 "k8s.io/api/core/v1"
+)
 
 type GeneratorSpec struct {
 	Templates []Template `json:"templates,omitempty"`
@@ -30,3 +33,33 @@ type SimulationJob struct {
 	Time float64     `json:"time"`
 	Job  v1.PintaJob `json:"job,omitempty"`
 }
+
+// ClusterSpec describes the synthetic cluster used by offline simulation.
+type ClusterSpec struct {
+	Nodes []NodeGroupSpec `json:"nodes,omitempty"`
+}
+
+// NodeGroupSpec describes a group of identical nodes.
+type NodeGroupSpec struct {
+	Type      string              `json:"type,omitempty"`
+	Count     int                 `json:"count,omitempty"`
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+}
+
+// SimulationResult is the outcome of an offline simulation. All times are in seconds of virtual time.
+type SimulationResult struct {
+	Policy              string                `json:"policy"`
+	Makespan            float64               `json:"makespan"`
+	AverageJCT          float64               `json:"averageJCT"`
+	AverageQueueingTime float64               `json:"averageQueueingTime"`
+	Jobs                []SimulationJobResult `json:"jobs"`
+}
+
+type SimulationJobResult struct {
+	Name         string  `json:"name"`
+	Arrival      float64 `json:"arrival"`
+	Start        float64 `json:"start"`
+	Finish       float64 `json:"finish"`
+	JCT          float64 `json:"jct"`
+	QueueingTime float64 `json:"queueingTime"`
+}
@@ -1,10 +1,8 @@
 package cache
 
 import (
-	"fmt"
 	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func (jc *jobCache) addOrUpdateNode(node *v1.Node) {
@@ -70,12 +68,13 @@ func (jc *jobCache) DeleteNode(node *v1.Node) {
 // TranslateResource converts PintaJob.Spec.Master/Replica.Resources to
 // Volcano Job.Spec.Tasks[*].Template.Spec.Containers[0].Resources.Limits.
 // Specifically, PintaJob supports specifying resources as "nodes". This
-// function maps "1 node" to all the resources each node has under nodeType.
+// function maps "1 node" to all the resources each node has under nodeType,
+// and fractions or multiples of a node to that share of the resources.
 func (jc *jobCache) TranslateResources(rl v1.ResourceList, nodeType string) (v1.ResourceList, error) {
 	jc.Lock()
 	defer jc.Unlock()
 
-	fractionNode, found := rl["node"]
+	fractionNode, found := rl[info.NodeResourceName]
 	// No "node" in ResourceList
 	if !found {
 		return rl, nil
@@ -85,18 +84,9 @@ func (jc *jobCache) TranslateResources(rl v1.ResourceList, nodeType string) (v1.
 		return rl, nil
 	}
 
-	one, _ := resource.ParseQuantity("1")
-	if !fractionNode.Equal(one) {
-		return nil, fmt.Errorf("resources.node != 1")
+	resource, err := info.NewRoleResource(rl, jc.nodeTypes, nodeType)
+	if err != nil {
+		return nil, err
 	}
-	if len(rl) != 1 {
-		return nil, fmt.Errorf("resources.node cannot be specified together with other resource types")
-	}
-
-	nodeTypeInfo, ok := jc.nodeTypes[nodeType]
-	if !ok {
-		return nil, fmt.Errorf("node type %v does not exist", nodeType)
-	}
-	oneNodeResource := nodeTypeInfo.Resource
-	return oneNodeResource.ToResourceList(), nil
+	return resource.ToResourceList(), nil
 }
@@ -33,7 +33,7 @@ func (rs *runningState) Execute() error {
 	pintaJobStatus := rs.updater.GetLastPintaJobStatus()
 	if pintaJobStatus.NumMasters == 0 && pintaJobStatus.NumReplicas == 0 {
 		// Running -> Preempted
-		return rs.updater.UpdatePintaJobStatusState(pintav1.Running)
+		return rs.updater.UpdatePintaJobStatusState(pintav1.Preempted)
 	}
 
 	return nil
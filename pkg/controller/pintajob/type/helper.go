@@ -2,6 +2,7 @@ package _type
 
 import (
 	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
 	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
 	v1 "k8s.io/api/core/v1"
 )
@@ -31,7 +32,12 @@ func patchPodSpecWithRoleSpec(podSpec *v1.PodSpec, roleSpec *pintav1.RoleSpec, t
 
 	patchNodeSelectorWithNodeType(podSpec.NodeSelector, roleSpec.NodeType)
 
-	resources, err := translateResources(roleSpec.Resources, roleSpec.NodeType)
+	// Masters and replicas of several nodes run as one pod per node
+	podResources, _, err := info.SplitRoleResources(roleSpec.Resources)
+	if err != nil {
+		return err
+	}
+	resources, err := translateResources(podResources, roleSpec.NodeType)
 	if err != nil {
 		return err
 	}
@@ -39,3 +45,22 @@ func patchPodSpecWithRoleSpec(podSpec *v1.PodSpec, roleSpec *pintav1.RoleSpec, t
 
 	return nil
 }
+
+// rolePods returns the # pods that numInstances masters or replicas of the role run as
+func rolePods(roleSpec *pintav1.RoleSpec, numInstances int32) int32 {
+	_, podsPerInstance, err := info.SplitRoleResources(roleSpec.Resources)
+	if err != nil {
+		// The pod spec cannot be patched with such resources, so the job is never built
+		return numInstances
+	}
+	return numInstances * podsPerInstance
+}
+
+// minAvailable returns the # pods of the smallest gang the job runs with, or numPods if it is given fewer
+func minAvailable(job *pintav1.PintaJob, numPods int32) int32 {
+	minMasters, _, minReplicas, _ := job.Spec.Bounds()
+	if gang := rolePods(&job.Spec.Master, minMasters) + rolePods(&job.Spec.Replica, minReplicas); gang < numPods {
+		return gang
+	}
+	return numPods
+}
@@ -17,7 +17,7 @@ type imageBuilder struct {
 func (ib *imageBuilder) BuildVCJob() (*volcanov1alpha1.Job, error) {
 	replicaSpec := volcanov1alpha1.TaskSpec{
 		Name:     "image-builder",
-		Replicas: 1,
+		Replicas: rolePods(&ib.job.Spec.Replica, 1),
 		Template: corev1.PodTemplateSpec{
 			Spec: *ib.job.Spec.Replica.Spec.DeepCopy(), // we are patching this below
 		},
@@ -36,10 +36,12 @@ func (ib *imageBuilder) BuildVCJob() (*volcanov1alpha1.Job, error) {
 			},
 		},
 		Spec: volcanov1alpha1.JobSpec{
-			SchedulerName: "volcano",
-			MinAvailable:  1,
-			Volumes:       ib.job.Spec.Volumes,
-			Tasks:         []volcanov1alpha1.TaskSpec{replicaSpec},
+			SchedulerName:     "volcano",
+			Queue:             ib.job.Spec.Queue,
+			PriorityClassName: ib.job.Spec.PriorityClassName,
+			MinAvailable:      replicaSpec.Replicas,
+			Volumes:           ib.job.Spec.Volumes,
+			Tasks:             []volcanov1alpha1.TaskSpec{replicaSpec},
 		},
 	}, nil
 }
@@ -49,10 +51,12 @@ func (ib *imageBuilder) ReconcileVCJob(vcJob *volcanov1alpha1.Job) (bool, error)
 		return false, fmt.Errorf("unexpected Volcano Job tasks during reconciliation, job was created incorrectly")
 	}
 
-	if vcJob.Spec.Tasks[0].Replicas == 1 {
+	numReplicaPods := rolePods(&ib.job.Spec.Replica, 1)
+	if vcJob.Spec.Tasks[0].Replicas == numReplicaPods && vcJob.Spec.MinAvailable == numReplicaPods {
 		return false, nil
 	}
 
-	vcJob.Spec.Tasks[0].Replicas = 1
+	vcJob.Spec.Tasks[0].Replicas = numReplicaPods
+	vcJob.Spec.MinAvailable = numReplicaPods
 	return true, nil
 }
@@ -22,7 +22,7 @@ func (m *mpi) BuildVCJob() (*volcanov1alpha1.Job, error) {
 
 	masterSpec := volcanov1alpha1.TaskSpec{
 		Name:     "master",
-		Replicas: lastPintaJobStatus.NumMasters,
+		Replicas: rolePods(&m.job.Spec.Master, lastPintaJobStatus.NumMasters),
 		Template: corev1.PodTemplateSpec{
 			Spec: *m.job.Spec.Master.Spec.DeepCopy(), // we are patching this below
 		},
@@ -40,7 +40,7 @@ func (m *mpi) BuildVCJob() (*volcanov1alpha1.Job, error) {
 
 	replicaSpec := volcanov1alpha1.TaskSpec{
 		Name:     "replica",
-		Replicas: lastPintaJobStatus.NumReplicas,
+		Replicas: rolePods(&m.job.Spec.Replica, lastPintaJobStatus.NumReplicas),
 		Template: corev1.PodTemplateSpec{
 			Spec: *m.job.Spec.Replica.Spec.DeepCopy(), // we are patching this below
 		},
@@ -59,10 +59,12 @@ func (m *mpi) BuildVCJob() (*volcanov1alpha1.Job, error) {
 			},
 		},
 		Spec: volcanov1alpha1.JobSpec{
-			SchedulerName: "volcano",
-			MinAvailable:  lastPintaJobStatus.NumMasters + lastPintaJobStatus.NumReplicas,
-			Volumes:       m.job.Spec.Volumes,
-			Tasks:         []volcanov1alpha1.TaskSpec{masterSpec, replicaSpec},
+			SchedulerName:     "volcano",
+			Queue:             m.job.Spec.Queue,
+			PriorityClassName: m.job.Spec.PriorityClassName,
+			MinAvailable:      minAvailable(m.job, masterSpec.Replicas+replicaSpec.Replicas),
+			Volumes:           m.job.Spec.Volumes,
+			Tasks:             []volcanov1alpha1.TaskSpec{masterSpec, replicaSpec},
 			Plugins: map[string][]string{
 				"env": {},
 				"svc": {},
@@ -82,11 +84,16 @@ func (m *mpi) ReconcileVCJob(vcJob *volcanov1alpha1.Job) (bool, error) {
 		lastPintaJobStatus = m.job.Status[0]
 	}
 
-	if vcJob.Spec.Tasks[0].Replicas == lastPintaJobStatus.NumMasters && vcJob.Spec.Tasks[1].Replicas == lastPintaJobStatus.NumReplicas {
+	numMasterPods := rolePods(&m.job.Spec.Master, lastPintaJobStatus.NumMasters)
+	numReplicaPods := rolePods(&m.job.Spec.Replica, lastPintaJobStatus.NumReplicas)
+	numMinAvailable := minAvailable(m.job, numMasterPods+numReplicaPods)
+	if vcJob.Spec.Tasks[0].Replicas == numMasterPods && vcJob.Spec.Tasks[1].Replicas == numReplicaPods &&
+		vcJob.Spec.MinAvailable == numMinAvailable {
 		return false, nil
 	}
 
-	vcJob.Spec.Tasks[0].Replicas = lastPintaJobStatus.NumMasters
-	vcJob.Spec.Tasks[1].Replicas = lastPintaJobStatus.NumReplicas
+	vcJob.Spec.Tasks[0].Replicas = numMasterPods
+	vcJob.Spec.Tasks[1].Replicas = numReplicaPods
+	vcJob.Spec.MinAvailable = numMinAvailable
 	return true, nil
 }
@@ -22,7 +22,7 @@ func (pw *psWorker) BuildVCJob() (*volcanov1alpha1.Job, error) {
 
 	masterSpec := volcanov1alpha1.TaskSpec{
 		Name:     "ps",
-		Replicas: lastPintaJobStatus.NumMasters,
+		Replicas: rolePods(&pw.job.Spec.Master, lastPintaJobStatus.NumMasters),
 		Template: corev1.PodTemplateSpec{
 			Spec: *pw.job.Spec.Master.Spec.DeepCopy(), // we are patching this below
 		},
@@ -35,7 +35,7 @@ func (pw *psWorker) BuildVCJob() (*volcanov1alpha1.Job, error) {
 
 	replicaSpec := volcanov1alpha1.TaskSpec{
 		Name:     "worker",
-		Replicas: lastPintaJobStatus.NumReplicas,
+		Replicas: rolePods(&pw.job.Spec.Replica, lastPintaJobStatus.NumReplicas),
 		Template: corev1.PodTemplateSpec{
 			Spec: *pw.job.Spec.Replica.Spec.DeepCopy(), // we are patching this below
 		},
@@ -60,10 +60,12 @@ func (pw *psWorker) BuildVCJob() (*volcanov1alpha1.Job, error) {
 			},
 		},
 		Spec: volcanov1alpha1.JobSpec{
-			SchedulerName: "volcano",
-			MinAvailable:  lastPintaJobStatus.NumMasters + lastPintaJobStatus.NumReplicas,
-			Volumes:       pw.job.Spec.Volumes,
-			Tasks:         []volcanov1alpha1.TaskSpec{masterSpec, replicaSpec},
+			SchedulerName:     "volcano",
+			Queue:             pw.job.Spec.Queue,
+			PriorityClassName: pw.job.Spec.PriorityClassName,
+			MinAvailable:      minAvailable(pw.job, masterSpec.Replicas+replicaSpec.Replicas),
+			Volumes:           pw.job.Spec.Volumes,
+			Tasks:             []volcanov1alpha1.TaskSpec{masterSpec, replicaSpec},
 			Plugins: map[string][]string{
 				"env": {},
 				"svc": {},
@@ -82,11 +84,16 @@ func (pw *psWorker) ReconcileVCJob(vcJob *volcanov1alpha1.Job) (bool, error) {
 		lastPintaJobStatus = pw.job.Status[0]
 	}
 
-	if vcJob.Spec.Tasks[0].Replicas == lastPintaJobStatus.NumMasters && vcJob.Spec.Tasks[1].Replicas == lastPintaJobStatus.NumReplicas {
+	numMasterPods := rolePods(&pw.job.Spec.Master, lastPintaJobStatus.NumMasters)
+	numReplicaPods := rolePods(&pw.job.Spec.Replica, lastPintaJobStatus.NumReplicas)
+	numMinAvailable := minAvailable(pw.job, numMasterPods+numReplicaPods)
+	if vcJob.Spec.Tasks[0].Replicas == numMasterPods && vcJob.Spec.Tasks[1].Replicas == numReplicaPods &&
+		vcJob.Spec.MinAvailable == numMinAvailable {
 		return false, nil
 	}
 
-	vcJob.Spec.Tasks[0].Replicas = lastPintaJobStatus.NumMasters
-	vcJob.Spec.Tasks[1].Replicas = lastPintaJobStatus.NumReplicas
+	vcJob.Spec.Tasks[0].Replicas = numMasterPods
+	vcJob.Spec.Tasks[1].Replicas = numReplicaPods
+	vcJob.Spec.MinAvailable = numMinAvailable
 	return true, nil
 }
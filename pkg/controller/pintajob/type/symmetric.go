@@ -22,7 +22,7 @@ func (s *symmetric) BuildVCJob() (*volcanov1alpha1.Job, error) {
 
 	replicaSpec := volcanov1alpha1.TaskSpec{
 		Name:     "replica",
-		Replicas: lastPintaJobStatus.NumReplicas,
+		Replicas: rolePods(&s.job.Spec.Replica, lastPintaJobStatus.NumReplicas),
 		Template: corev1.PodTemplateSpec{
 			Spec: *s.job.Spec.Replica.Spec.DeepCopy(), // we are patching this below
 		},
@@ -47,10 +47,12 @@ func (s *symmetric) BuildVCJob() (*volcanov1alpha1.Job, error) {
 			},
 		},
 		Spec: volcanov1alpha1.JobSpec{
-			SchedulerName: "volcano",
-			MinAvailable:  lastPintaJobStatus.NumReplicas,
-			Volumes:       s.job.Spec.Volumes,
-			Tasks:         []volcanov1alpha1.TaskSpec{replicaSpec},
+			SchedulerName:     "volcano",
+			Queue:             s.job.Spec.Queue,
+			PriorityClassName: s.job.Spec.PriorityClassName,
+			MinAvailable:      minAvailable(s.job, replicaSpec.Replicas),
+			Volumes:           s.job.Spec.Volumes,
+			Tasks:             []volcanov1alpha1.TaskSpec{replicaSpec},
 			Plugins: map[string][]string{
 				"env": {},
 				"svc": {},
@@ -69,10 +71,13 @@ func (s *symmetric) ReconcileVCJob(vcJob *volcanov1alpha1.Job) (bool, error) {
 		lastPintaJobStatus = s.job.Status[0]
 	}
 
-	if vcJob.Spec.Tasks[0].Replicas == lastPintaJobStatus.NumReplicas {
+	numReplicaPods := rolePods(&s.job.Spec.Replica, lastPintaJobStatus.NumReplicas)
+	numMinAvailable := minAvailable(s.job, numReplicaPods)
+	if vcJob.Spec.Tasks[0].Replicas == numReplicaPods && vcJob.Spec.MinAvailable == numMinAvailable {
 		return false, nil
 	}
 
-	vcJob.Spec.Tasks[0].Replicas = lastPintaJobStatus.NumReplicas
+	vcJob.Spec.Tasks[0].Replicas = numReplicaPods
+	vcJob.Spec.MinAvailable = numMinAvailable
 	return true, nil
 }
@@ -7,9 +7,11 @@ import (
 	controllercache "github.com/qed-usc/pinta-scheduler/pkg/controller/cache"
 	pintajobtype "github.com/qed-usc/pinta-scheduler/pkg/controller/pintajob/type"
 	pintaclientset "github.com/qed-usc/pinta-scheduler/pkg/generated/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
 	volcanov1alpha1 "volcano.sh/volcano/pkg/apis/batch/v1alpha1"
+	volcanov1beta1 "volcano.sh/volcano/pkg/apis/scheduling/v1beta1"
 	vcclientset "volcano.sh/volcano/pkg/client/clientset/versioned"
 )
 
@@ -67,6 +69,11 @@ func (u *Updater) UpdatePintaJobStatusState(state pintav1.PintaJobState) error {
 			LastTransitionTime: metav1.Now(),
 			NumMasters:         lastPintaJobStatus.NumMasters,
 			NumReplicas:        lastPintaJobStatus.NumReplicas,
+			Reason:             lastPintaJobStatus.Reason,
+			Message:            lastPintaJobStatus.Message,
+
+			EstimatedStartTime:      lastPintaJobStatus.EstimatedStartTime,
+			EstimatedCompletionTime: lastPintaJobStatus.EstimatedCompletionTime,
 		},
 	}, pintaJobCopy.Status...)
 
@@ -94,6 +101,14 @@ func (u *Updater) Reconcile() error {
 	var newVCJob *volcanov1alpha1.Job
 	var err error
 	if vcJob == nil {
+		if err = pintaJob.Spec.Validate(); err != nil {
+			klog.Errorf("PintaJob <%v/%v> is invalid: %v", pintaJob.Namespace, pintaJob.Name, err)
+			return err
+		}
+		if err = u.ensureVCQueue(pintaJob.Spec.Queue); err != nil {
+			klog.Errorf("Volcano Queue <%v> for PintaJob <%v/%v> creation failed: %v", pintaJob.Spec.Queue, pintaJob.Namespace, pintaJob.Name, err)
+			return err
+		}
 		newVCJob, err = pintaJobType.BuildVCJob()
 		if err != nil {
 			klog.Errorf("Building Volcano Job <%v/%v> failed: %v", u.jobInfo.Namespace, u.jobInfo.Namespace, err)
@@ -121,3 +136,28 @@ func (u *Updater) Reconcile() error {
 
 	return u.cache.UpdateVCJob(newVCJob)
 }
+
+// ensureVCQueue creates the Volcano Queue of the same name as the PintaQueue of the job if it does not
+// exist. Capacity is enforced by the Pinta scheduler, so the Volcano Queue is left unlimited.
+func (u *Updater) ensureVCQueue(name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := u.vcClient.SchedulingV1beta1().Queues().Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+	queue := &volcanov1beta1.Queue{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: volcanov1beta1.QueueSpec{
+			Weight: 1,
+		},
+	}
+	_, err = u.vcClient.SchedulingV1beta1().Queues().Create(context.TODO(), queue, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
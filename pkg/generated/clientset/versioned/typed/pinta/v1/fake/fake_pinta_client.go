@@ -32,6 +32,10 @@ func (c *FakePintaV1) PintaJobs(namespace string) v1.PintaJobInterface {
 	return &FakePintaJobs{c, namespace}
 }
 
+func (c *FakePintaV1) PintaQueues() v1.PintaQueueInterface {
+	return &FakePintaQueues{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakePintaV1) RESTClient() rest.Interface {
@@ -27,6 +27,7 @@ import (
 type PintaV1Interface interface {
 	RESTClient() rest.Interface
 	PintaJobsGetter
+	PintaQueuesGetter
 }
 
 // PintaV1Client is used to interact with features provided by the pinta.qed.usc.edu group.
@@ -38,6 +39,10 @@ func (c *PintaV1Client) PintaJobs(namespace string) PintaJobInterface {
 	return newPintaJobs(c, namespace)
 }
 
+func (c *PintaV1Client) PintaQueues() PintaQueueInterface {
+	return newPintaQueues(c)
+}
+
 // NewForConfig creates a new PintaV1Client for the given config.
 func NewForConfig(c *rest.Config) (*PintaV1Client, error) {
 	config := *c
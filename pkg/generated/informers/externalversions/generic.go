@@ -55,6 +55,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=pinta.qed.usc.edu, Version=v1
 	case v1.SchemeGroupVersion.WithResource("pintajobs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Pinta().V1().PintaJobs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("pintaqueues"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Pinta().V1().PintaQueues().Informer()}, nil
 
 	}
 
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// PintaJobs returns a PintaJobInformer.
 	PintaJobs() PintaJobInformer
+	// PintaQueues returns a PintaQueueInformer.
+	PintaQueues() PintaQueueInformer
 }
 
 type version struct {
@@ -43,3 +45,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) PintaJobs() PintaJobInformer {
 	return &pintaJobInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// PintaQueues returns a PintaQueueInformer.
+func (v *version) PintaQueues() PintaQueueInformer {
+	return &pintaQueueInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -25,3 +25,7 @@ type PintaJobListerExpansion interface{}
 // PintaJobNamespaceListerExpansion allows custom methods to be added to
 // PintaJobNamespaceLister.
 type PintaJobNamespaceListerExpansion interface{}
+
+// PintaQueueListerExpansion allows custom methods to be added to
+// PintaQueueLister.
+type PintaQueueListerExpansion interface{}
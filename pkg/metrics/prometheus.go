@@ -1,6 +1,10 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 const subsysPintaJob string = "pinta_job"
 
@@ -66,3 +70,106 @@ func RegisterPintaJob() {
 	prometheus.MustRegister(pintaJobQueueTime, pintaJobScheduleTime, pintaJobPendingTime, pintaJobServiceTime, pintaJobTime)
 	prometheus.MustRegister(totalPintaJobs, scheduledPintaJobs, succeededPintaJobs, preemptedPintaJobs)
 }
+
+const subsysScheduler string = "pinta_scheduler"
+
+var (
+	nodesUsed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "nodes_used",
+		Help:      "Number of nodes the allocations of the latest scheduling cycle use.",
+	})
+
+	jobsStarved = prometheus.NewGauge(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "jobs_starved",
+		Help:      "Number of jobs the latest scheduling cycle allocates nothing.",
+	})
+
+	shadowNodesUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "shadow_nodes_used",
+		Help:      "Number of nodes the allocations of a shadow policy in the latest scheduling cycle would use.",
+	}, []string{"policy"})
+
+	shadowJobsStarved = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "shadow_jobs_starved",
+		Help:      "Number of jobs a shadow policy in the latest scheduling cycle would allocate nothing.",
+	}, []string{"policy"})
+
+	shadowJobsDiffering = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "shadow_jobs_differing",
+		Help:      "Number of jobs a shadow policy in the latest scheduling cycle would allocate differently.",
+	}, []string{"policy"})
+
+	jobEstimatedStartTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "job_estimated_start_time_seconds",
+		Help:      "Unix time a waiting job is expected to start at, as of the latest scheduling cycle.",
+	}, []string{"job"})
+
+	jobEstimatedCompletionTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsysScheduler,
+		Name:      "job_estimated_completion_time_seconds",
+		Help:      "Unix time a running job is expected to complete at, as of the latest scheduling cycle.",
+	}, []string{"job"})
+
+	extenderFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: subsysScheduler,
+		Name:      "extender_fallbacks_total",
+		Help:      "Number of scheduling cycles the extender policy fell back to its fallback policy, by reason.",
+	}, []string{"reason"})
+)
+
+func RegisterScheduler() {
+	prometheus.MustRegister(nodesUsed, jobsStarved)
+	prometheus.MustRegister(shadowNodesUsed, shadowJobsStarved, shadowJobsDiffering)
+	prometheus.MustRegister(jobEstimatedStartTime, jobEstimatedCompletionTime)
+	prometheus.MustRegister(extenderFallbacks)
+}
+
+// UpdateAllocation records the summary of the allocations of a scheduling cycle
+func UpdateAllocation(numNodesUsed, numJobsStarved int) {
+	nodesUsed.Set(float64(numNodesUsed))
+	jobsStarved.Set(float64(numJobsStarved))
+}
+
+// UpdateShadowPolicy records the summary of the allocations of a shadow policy, and the # jobs it
+// allocates differently
+func UpdateShadowPolicy(policy string, numNodesUsed, numJobsStarved, numJobsDiffering int) {
+	shadowNodesUsed.WithLabelValues(policy).Set(float64(numNodesUsed))
+	shadowJobsStarved.WithLabelValues(policy).Set(float64(numJobsStarved))
+	shadowJobsDiffering.WithLabelValues(policy).Set(float64(numJobsDiffering))
+}
+
+// ResetShadowPolicies drops the metrics of the shadow policies, once they are replaced
+func ResetShadowPolicies() {
+	shadowNodesUsed.Reset()
+	shadowJobsStarved.Reset()
+	shadowJobsDiffering.Reset()
+}
+
+// ResetJobEstimates drops the estimates of the jobs, before the ones of a new scheduling cycle are recorded
+func ResetJobEstimates() {
+	jobEstimatedStartTime.Reset()
+	jobEstimatedCompletionTime.Reset()
+}
+
+// UpdateJobEstimate records when the job is expected to start and complete. Zero times are unknown and
+// not recorded.
+func UpdateJobEstimate(job string, start, completion time.Time) {
+	if !start.IsZero() {
+		jobEstimatedStartTime.WithLabelValues(job).Set(float64(start.Unix()))
+	}
+	if !completion.IsZero() {
+		jobEstimatedCompletionTime.WithLabelValues(job).Set(float64(completion.Unix()))
+	}
+}
+
+// RecordExtenderFallback counts a scheduling cycle the extender policy fell back for the reason, either
+// unavailable or invalid
+func RecordExtenderFallback(reason string) {
+	extenderFallbacks.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Log appends records to a file as JSON lines. Once the file grows beyond maxSize bytes, it is rotated
+// to <path>.1, the previous <path>.1 to <path>.2 and so on, keeping maxBackups rotated files.
+type Log struct {
+	sync.Mutex
+
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewLog opens the log at path, appending to it if it exists. A maxSize of 0 never rotates the file.
+func NewLog(path string, maxSize int64, maxBackups int) (*Log, error) {
+	l := &Log{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open audit log: %v", err)
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot open audit log: %v", err)
+	}
+	l.file = file
+	l.size = fileInfo.Size()
+	return nil
+}
+
+// Write appends the record to the log, rotating the file first if the record would grow it beyond its
+// max size
+func (l *Log) Write(record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("cannot encode audit record: %v", err)
+	}
+	line = append(line, '\n')
+
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("cannot write audit record to %s: %v", l.path, err)
+	}
+	return nil
+}
+
+// rotate shifts the rotated files, dropping the oldest one, and starts a new file.
+// Assumes that lock is already acquired.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("cannot close audit log: %v", err)
+	}
+	if l.maxBackups <= 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate audit log: %v", err)
+		}
+		return l.open()
+	}
+	for i := l.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backupPath(l.path, i), backupPath(l.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate audit log: %v", err)
+		}
+	}
+	if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+		return fmt.Errorf("cannot rotate audit log: %v", err)
+	}
+	return l.open()
+}
+
+// Close closes the file of the log
+func (l *Log) Close() error {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	return l.file.Close()
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// ReadRecords reads the records of the log file at path, in the order they are written
+func ReadRecords(path string) ([]*Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []*Record
+	// Records of large clusters do not fit in the buffer of a bufio.Scanner
+	reader := bufio.NewReader(file)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			record := &Record{}
+			if parseErr := json.Unmarshal(line, record); parseErr != nil {
+				if err == io.EOF {
+					// The last record is cut short if the scheduler stopped while writing it
+					return records, nil
+				}
+				return nil, fmt.Errorf("%s:%d: cannot parse audit record: %v", path, lineNum, parseErr)
+			}
+			records = append(records, record)
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// FindRecord returns the record of the session in the log file at path, or the latest record if session
+// is empty
+func FindRecord(path string, session types.UID) (*Record, error) {
+	records, err := ReadRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no audit record", path)
+	}
+	if session == "" {
+		return records[len(records)-1], nil
+	}
+	for _, record := range records {
+		if record.Session == session {
+			return record, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no audit record of session %s", path, session)
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestLog_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	// Each record is 80 bytes, so every file holds two
+	l, err := NewLog(path, 200, 2)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		if err := l.Write(&Record{Session: types.UID(fmt.Sprintf("s%d", i))}); err != nil {
+			t.Fatalf("Failed to write record %d: %v", i, err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Failed to close log: %v", err)
+	}
+
+	expected := map[string][]types.UID{
+		path:        {"s6"},
+		path + ".1": {"s4", "s5"},
+		path + ".2": {"s2", "s3"},
+	}
+	for file, sessions := range expected {
+		records, err := ReadRecords(file)
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", file, err)
+			continue
+		}
+		if len(records) != len(sessions) {
+			t.Errorf("Expected %d records in %s, got %d", len(sessions), file, len(records))
+			continue
+		}
+		for i, record := range records {
+			if record.Session != sessions[i] {
+				t.Errorf("Expected record %d of %s to be of session %s, got %s", i, file, sessions[i], record.Session)
+			}
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 rotated files to be kept")
+	}
+
+	if record, err := FindRecord(path+".1", ""); err != nil || record.Session != "s5" {
+		t.Errorf("Expected the latest record to be of session s5, got %+v, %v", record, err)
+	}
+	if _, err := FindRecord(path, "s0"); err == nil {
+		t.Errorf("Expected no record of session s0")
+	}
+}
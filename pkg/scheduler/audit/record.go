@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/conf"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+// Record is what the audit log keeps of a scheduling cycle: everything the policies are given, and the
+// allocations they make
+type Record struct {
+	Session types.UID   `json:"session"`
+	Time    metav1.Time `json:"time"`
+
+	// Policies are the policies pipeline and their configurations
+	Policies []conf.PolicyOption `json:"policies"`
+	// Snapshot is the cluster the policies start from
+	Snapshot *Snapshot `json:"snapshot"`
+	// JobStates are the states the stateful policies kept for each job when the cycle started
+	JobStates map[info.JobID]map[string]string `json:"jobStates,omitempty"`
+
+	// Allocations are the allocations of the policies, in order of job ID
+	Allocations []session.Allocation `json:"allocations"`
+}
+
+// Snapshot is the serializable form of an info.ClusterInfo. Everything else in the ClusterInfo is derived
+// from it.
+type Snapshot struct {
+	Jobs   []Job                `json:"jobs"`
+	Nodes  []v1.Node            `json:"nodes"`
+	Queues []pintav1.PintaQueue `json:"queues,omitempty"`
+}
+
+// Job is a PintaJob along with what the cache resolves for it
+type Job struct {
+	ID          info.JobID        `json:"id"`
+	Job         *pintav1.PintaJob `json:"job"`
+	Priority    int32             `json:"priority,omitempty"`
+	Preemptible bool              `json:"preemptible"`
+	Progress    *info.JobProgress `json:"progress,omitempty"`
+}
+
+// Capture starts the record of the cycle of the session from the snapshot the session starts from,
+// before the policies are executed. The configurations are the ones of the policies, in the same order.
+func Capture(
+	ssn *session.Session,
+	snapshot *info.ClusterInfo,
+	policies []session.Policy,
+	configurations []conf.Configuration,
+	store cache.JobStateStore,
+) *Record {
+	record := &Record{
+		Session:  ssn.UID,
+		Time:     metav1.NewTime(ssn.Now()),
+		Snapshot: NewSnapshot(snapshot),
+	}
+	for i, policy := range policies {
+		option := conf.PolicyOption{Name: policy.Name()}
+		if i < len(configurations) {
+			option.Configuration = configurations[i]
+		}
+		record.Policies = append(record.Policies, option)
+
+		if _, stateful := policy.(session.StatefulPolicy); !stateful {
+			continue
+		}
+		for _, job := range record.Snapshot.Jobs {
+			state, found, err := store.Get(job.ID, policy.Name())
+			if err != nil {
+				klog.Errorf("Cannot record state of policy %s for job %v: %v", policy.Name(), job.ID, err)
+				break
+			}
+			if !found {
+				continue
+			}
+			if record.JobStates == nil {
+				record.JobStates = make(map[info.JobID]map[string]string)
+			}
+			if record.JobStates[job.ID] == nil {
+				record.JobStates[job.ID] = make(map[string]string)
+			}
+			record.JobStates[job.ID][policy.Name()] = state
+		}
+	}
+	return record
+}
+
+// Complete records the allocations of the session, after the policies are executed
+func (r *Record) Complete(ssn *session.Session) {
+	r.Allocations = ssn.Allocations()
+}
+
+// NewSnapshot returns the serializable form of the cluster
+func NewSnapshot(ci *info.ClusterInfo) *Snapshot {
+	snapshot := &Snapshot{}
+	for id, job := range ci.Jobs {
+		snapshot.Jobs = append(snapshot.Jobs, Job{
+			ID:          id,
+			Job:         job.Job.DeepCopy(),
+			Priority:    job.Priority,
+			Preemptible: job.Preemptible,
+			Progress:    job.Progress,
+		})
+	}
+	for _, node := range ci.Nodes {
+		snapshot.Nodes = append(snapshot.Nodes, *node.Node.DeepCopy())
+	}
+	for _, queue := range ci.Queues {
+		snapshot.Queues = append(snapshot.Queues, *queue.Queue.DeepCopy())
+	}
+	sort.Slice(snapshot.Jobs, func(i, j int) bool {
+		return snapshot.Jobs[i].ID < snapshot.Jobs[j].ID
+	})
+	sort.Slice(snapshot.Nodes, func(i, j int) bool {
+		return snapshot.Nodes[i].Name < snapshot.Nodes[j].Name
+	})
+	sort.Slice(snapshot.Queues, func(i, j int) bool {
+		return snapshot.Queues[i].Name < snapshot.Queues[j].Name
+	})
+	return snapshot
+}
+
+// ClusterInfo rebuilds the cluster the snapshot is taken of. Job custom fields are not parsed.
+func (s *Snapshot) ClusterInfo() *info.ClusterInfo {
+	ci := info.NewClusterInfo()
+	for _, job := range s.Jobs {
+		ji := info.NewJobInfo(job.ID, job.Job.DeepCopy())
+		ji.Priority = job.Priority
+		ji.Preemptible = job.Preemptible
+		if job.Progress != nil {
+			progress := *job.Progress
+			ji.Progress = &progress
+		}
+		ci.Jobs[job.ID] = ji
+	}
+	for i := range s.Nodes {
+		ci.Nodes[s.Nodes[i].Name] = info.NewNodeInfo(s.Nodes[i].DeepCopy())
+	}
+	for i := range s.Queues {
+		ci.Queues[s.Queues[i].Name] = info.NewQueueInfo(s.Queues[i].DeepCopy())
+	}
+	return ci
+}
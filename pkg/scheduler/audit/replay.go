@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+// ReplayResult is the outcome of executing policies on a recorded cycle
+type ReplayResult struct {
+	Session types.UID   `json:"session"`
+	Time    metav1.Time `json:"time"`
+
+	// Policies are the policies replayed
+	Policies []string `json:"policies"`
+	// Allocations are the allocations of the replayed policies, in order of job ID
+	Allocations []session.Allocation `json:"allocations"`
+	// Differences are the jobs the replayed policies allocate differently than the recorded ones, in order
+	// of job ID
+	Differences []session.JobDiff `json:"differences,omitempty"`
+}
+
+// Replay executes the policies on the snapshot of the record at the time of the record, starting from the
+// recorded job states, and compares their allocations with the recorded ones. Nothing is committed.
+func Replay(record *Record, policies []session.Policy) (*ReplayResult, error) {
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no policy to replay")
+	}
+
+	snapshot := record.Snapshot.ClusterInfo()
+	jobCustomFieldsType := policies[0].JobCustomFieldsType()
+	for _, job := range snapshot.Jobs {
+		if err := job.ParseCustomFields(jobCustomFieldsType); err != nil {
+			klog.Errorf("Cannot parse custom fields for job %v: %v", job.Name, err)
+		}
+	}
+	store := cache.NewMemoryJobStateStore()
+	for id, states := range record.JobStates {
+		for policy, state := range states {
+			store.Set(id, policy, state)
+		}
+	}
+
+	result := &ReplayResult{
+		Session: record.Session,
+		Time:    record.Time,
+	}
+	for _, policy := range policies {
+		policy.Initialize()
+		defer policy.UnInitialize()
+		result.Policies = append(result.Policies, policy.Name())
+	}
+
+	ssn := session.OpenSessionWithSnapshot(snapshot, store, record.Time.Time)
+	session.ExecutePolicies(ssn, policies)
+	result.Allocations = ssn.Allocations()
+
+	recorded := make(map[info.JobID]session.Allocation, len(record.Allocations))
+	for _, allocation := range record.Allocations {
+		recorded[allocation.Job] = allocation
+	}
+	for _, allocation := range result.Allocations {
+		reference, found := recorded[allocation.Job]
+		if !found || (allocation.NumMasters == reference.NumMasters && allocation.NumReplicas == reference.NumReplicas) {
+			continue
+		}
+		var state pintav1.PintaJobState
+		if job := snapshot.Jobs[allocation.Job]; len(job.Job.Status) > 0 {
+			state = job.Job.Status[0].State
+		}
+		result.Differences = append(result.Differences, session.JobDiff{
+			Job:   allocation.Job,
+			State: state,
+
+			NumMasters:          reference.NumMasters,
+			NumReplicas:         reference.NumReplicas,
+			ProposedNumMasters:  allocation.NumMasters,
+			ProposedNumReplicas: allocation.NumReplicas,
+
+			Reason:  allocation.Reason,
+			Message: allocation.Message,
+		})
+	}
+	sort.Slice(result.Differences, func(i, j int) bool {
+		return result.Differences[i].Job < result.Differences[j].Job
+	})
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/conf"
+	_ "github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/equi"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/nop"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+func TestReplay(t *testing.T) {
+	mc := cache.NewMemoryCache()
+	for i := 0; i < 2; i++ {
+		mc.AddNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("n%d", i)},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		})
+	}
+	for name, numReplicas := range map[string]int{"j1": 1, "j2": 0} {
+		mc.AddJob(&pintav1.PintaJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: map[string]string{"pinta.qed.usc.edu/custom-fields": fmt.Sprintf("{numReplicas: %d}", numReplicas)},
+			},
+			Spec:   pintav1.PintaJobSpec{Type: pintav1.Symmetric},
+			Status: []pintav1.PintaJobStatus{{State: pintav1.Idle}},
+		})
+	}
+
+	// Record a cycle of nop, and read it back from the log
+	policy, err := nop.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	policies := []session.Policy{policy}
+	ssn := session.OpenSession(nil, mc, policies)
+	record := Capture(ssn, ssn.Snapshot(), policies, []conf.Configuration{{}}, mc.JobStateStore())
+	session.ExecutePolicies(ssn, policies)
+	record.Complete(ssn)
+	session.CloseSession(ssn)
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	l, err := NewLog(path, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if err := l.Write(record); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	_ = l.Close()
+	recorded, err := FindRecord(path, record.Session)
+	if err != nil {
+		t.Fatalf("Failed to find record: %v", err)
+	}
+
+	// The recorded policies make the same allocations
+	replayPolicies, _, err := session.BuildPolicies(recorded.Policies)
+	if err != nil {
+		t.Fatalf("Failed to build recorded policies: %v", err)
+	}
+	result, err := Replay(recorded, replayPolicies)
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if !reflect.DeepEqual(result.Allocations, record.Allocations) || len(result.Differences) != 0 {
+		t.Errorf("Expected allocations %+v without differences, got %+v with differences %+v",
+			record.Allocations, result.Allocations, result.Differences)
+	}
+
+	// Another policy allocates differently
+	shadow, err := equi.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	result, err = Replay(recorded, []session.Policy{shadow})
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	expected := []session.JobDiff{
+		{
+			Job:                 "default/j2",
+			State:               pintav1.Idle,
+			ProposedNumReplicas: 1,
+			Reason:              session.ReasonScheduled,
+			Message:             "equal share of 1 replicas",
+		},
+	}
+	if !reflect.DeepEqual(result.Differences, expected) {
+		t.Errorf("Expected differences %+v, got %+v", expected, result.Differences)
+	}
+}
@@ -15,10 +15,13 @@ import (
 	"sync"
 
 	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
 	clientset "github.com/qed-usc/pinta-scheduler/pkg/generated/clientset/versioned"
 	pintainformers "github.com/qed-usc/pinta-scheduler/pkg/generated/informers/externalversions"
 	ptjobinformers "github.com/qed-usc/pinta-scheduler/pkg/generated/informers/externalversions/pinta/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	kubeinformers "k8s.io/client-go/informers/core/v1"
+	schedulinginformers "k8s.io/client-go/informers/scheduling/v1"
 	volcanoclientset "volcano.sh/volcano/pkg/client/clientset/versioned"
 	volcanoinformers "volcano.sh/volcano/pkg/client/informers/externalversions"
 	vcjobinformers "volcano.sh/volcano/pkg/client/informers/externalversions/batch/v1alpha1"
@@ -26,28 +29,43 @@ import (
 
 type PintaCache struct {
 	sync.Mutex
+	changeNotifier
 
 	kubeClient  *kubernetes.Clientset
 	vcClient    *volcanoclientset.Clientset
 	pintaClient *clientset.Clientset
 
-	nodeInformer  kubeinformers.NodeInformer
-	vcInformer    vcjobinformers.JobInformer
-	pintaInformer ptjobinformers.PintaJobInformer
+	nodeInformer       kubeinformers.NodeInformer
+	vcInformer         vcjobinformers.JobInformer
+	pintaInformer      ptjobinformers.PintaJobInformer
+	pintaQueueInformer ptjobinformers.PintaQueueInformer
+	// priorityClassInformer is nil if PriorityClasses are disabled
+	priorityClassInformer schedulinginformers.PriorityClassInformer
 
 	JobInfoUpdater JobInfoUpdater
 
+	jobStateStore JobStateStore
+
 	Recorder record.EventRecorder
 
-	Jobs  map[info.JobID]*info.JobInfo
-	Nodes map[string]*info.NodeInfo
+	Jobs   map[info.JobID]*info.JobInfo
+	Nodes  map[string]*info.NodeInfo
+	Queues map[string]*info.QueueInfo
+	// PriorityClasses is nil if PriorityClasses are disabled, and every job has priority 0
+	PriorityClasses map[string]*schedulingv1.PriorityClass
+
+	// progress is the latest progress reported by each job
+	progress map[info.JobID]*info.JobProgress
 }
 
-func New(config *rest.Config) Cache {
-	return newPintaCache(config)
+// New returns a cache of the cluster behind config. Job states are persisted in stateConfigMap, given as
+// "namespace/name", or only kept in memory if it is empty. Job priorities are resolved from
+// PriorityClasses if enablePriorityClass is set.
+func New(config *rest.Config, stateConfigMap string, enablePriorityClass bool) Cache {
+	return newPintaCache(config, stateConfigMap, enablePriorityClass)
 }
 
-func newPintaCache(config *rest.Config) Cache {
+func newPintaCache(config *rest.Config, stateConfigMap string, enablePriorityClass bool) Cache {
 	kubeClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		panic(fmt.Sprintf("Kubernetes clientset initialization failed: %v", err))
@@ -66,8 +84,12 @@ func newPintaCache(config *rest.Config) Cache {
 	}
 
 	sc := &PintaCache{
+		changeNotifier: newChangeNotifier(),
+
 		Jobs:        make(map[info.JobID]*info.JobInfo),
 		Nodes:       make(map[string]*info.NodeInfo),
+		Queues:      make(map[string]*info.QueueInfo),
+		progress:    make(map[info.JobID]*info.JobProgress),
 		kubeClient:  kubeClient,
 		vcClient:    vcClient,
 		pintaClient: client,
@@ -82,6 +104,15 @@ func newPintaCache(config *rest.Config) Cache {
 		pintaClient: client,
 	}
 
+	if stateConfigMap == "" {
+		sc.jobStateStore = NewMemoryJobStateStore()
+	} else {
+		sc.jobStateStore, err = NewConfigMapJobStateStore(kubeClient, stateConfigMap)
+		if err != nil {
+			panic(fmt.Sprintf("Job state store initialization failed: %v", err))
+		}
+	}
+
 	informerFactory := informers.NewSharedInformerFactory(sc.kubeClient, 0)
 	sc.nodeInformer = informerFactory.Core().V1().Nodes()
 	sc.nodeInformer.Informer().AddEventHandlerWithResyncPeriod(
@@ -92,6 +123,15 @@ func newPintaCache(config *rest.Config) Cache {
 		},
 		0,
 	)
+	if enablePriorityClass {
+		sc.PriorityClasses = make(map[string]*schedulingv1.PriorityClass)
+		sc.priorityClassInformer = informerFactory.Scheduling().V1().PriorityClasses()
+		sc.priorityClassInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    sc.AddPriorityClass,
+			UpdateFunc: sc.UpdatePriorityClass,
+			DeleteFunc: sc.DeletePriorityClass,
+		})
+	}
 
 	vcinformers := volcanoinformers.NewSharedInformerFactory(sc.vcClient, 0)
 	sc.vcInformer = vcinformers.Batch().V1alpha1().Jobs()
@@ -108,6 +148,12 @@ func newPintaCache(config *rest.Config) Cache {
 		UpdateFunc: sc.UpdateJob,
 		DeleteFunc: sc.DeleteJob,
 	})
+	sc.pintaQueueInformer = ptinformers.Pinta().V1().PintaQueues()
+	sc.pintaQueueInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    sc.AddQueue,
+		UpdateFunc: sc.UpdateQueue,
+		DeleteFunc: sc.DeleteQueue,
+	})
 
 	return sc
 }
@@ -116,6 +162,10 @@ func (sc *PintaCache) Run(stopCh <-chan struct{}) {
 	go sc.nodeInformer.Informer().Run(stopCh)
 	go sc.vcInformer.Informer().Run(stopCh)
 	go sc.pintaInformer.Informer().Run(stopCh)
+	go sc.pintaQueueInformer.Informer().Run(stopCh)
+	if sc.priorityClassInformer != nil {
+		go sc.priorityClassInformer.Informer().Run(stopCh)
+	}
 }
 
 // Synchronize the cache with apiserver
@@ -126,6 +176,10 @@ func (sc *PintaCache) WaitForCacheSync(stopCh <-chan struct{}) bool {
 				sc.nodeInformer.Informer().HasSynced,
 				sc.vcInformer.Informer().HasSynced,
 				sc.pintaInformer.Informer().HasSynced,
+				sc.pintaQueueInformer.Informer().HasSynced,
+			}
+			if sc.priorityClassInformer != nil {
+				informerSynced = append(informerSynced, sc.priorityClassInformer.Informer().HasSynced)
 			}
 			return informerSynced
 		}()...,
@@ -136,9 +190,21 @@ func (sc *PintaCache) Snapshot(jobCustomFieldsType reflect.Type) *info.ClusterIn
 	sc.Mutex.Lock()
 	defer sc.Mutex.Unlock()
 
+	return snapshot(sc.Jobs, sc.Nodes, sc.Queues, sc.PriorityClasses, sc.progress, jobCustomFieldsType)
+}
+
+// snapshot deep copies the schedulable jobs, nodes and queues. Assumes that lock is already acquired.
+func snapshot(
+	jobs map[info.JobID]*info.JobInfo,
+	nodes map[string]*info.NodeInfo,
+	queues map[string]*info.QueueInfo,
+	priorityClasses map[string]*schedulingv1.PriorityClass,
+	progress map[info.JobID]*info.JobProgress,
+	jobCustomFieldsType reflect.Type,
+) *info.ClusterInfo {
 	snapshot := info.NewClusterInfo()
 
-	for _, value := range sc.Nodes {
+	for _, value := range nodes {
 		if !value.Ready() {
 			continue
 		}
@@ -151,16 +217,48 @@ func (sc *PintaCache) Snapshot(jobCustomFieldsType reflect.Type) *info.ClusterIn
 		snapshot.Nodes[value.Name] = value.Clone()
 	}
 
+	for name, value := range queues {
+		snapshot.Queues[name] = value.Clone()
+	}
+
+	var defaultPriorityClass *schedulingv1.PriorityClass
+	for _, priorityClass := range priorityClasses {
+		if priorityClass.GlobalDefault && (defaultPriorityClass == nil || priorityClass.Value > defaultPriorityClass.Value) {
+			defaultPriorityClass = priorityClass
+		}
+	}
+
 	var cloneJobLock sync.Mutex
 	var wg sync.WaitGroup
 
 	cloneJob := func(value *info.JobInfo) {
 		defer wg.Done()
 
+		if err := value.Job.Spec.Validate(); err != nil {
+			klog.Errorf("Job <%s/%s> is invalid and not scheduled: %v", value.Namespace, value.Name, err)
+			return
+		}
+
 		clonedJob := value.Clone()
-		err := clonedJob.ParseCustomFields(jobCustomFieldsType)
-		if err != nil {
-			klog.Errorf("Cannot parse custom fields for job %v: %v", clonedJob.Name, err)
+		if jobProgress, found := progress[value.UID]; found {
+			jobProgressCopy := *jobProgress
+			clonedJob.Progress = &jobProgressCopy
+		}
+		if priorityClasses != nil {
+			name := clonedJob.Job.Spec.PriorityClassName
+			if priorityClass, found := priorityClasses[name]; found {
+				clonedJob.SetPriorityClass(priorityClass)
+			} else if name != "" {
+				klog.Warningf("PriorityClass %s of job <%s/%s> does not exist", name, clonedJob.Namespace, clonedJob.Name)
+			} else if defaultPriorityClass != nil {
+				clonedJob.SetPriorityClass(defaultPriorityClass)
+			}
+		}
+		if jobCustomFieldsType != nil {
+			err := clonedJob.ParseCustomFields(jobCustomFieldsType)
+			if err != nil {
+				klog.Errorf("Cannot parse custom fields for job %v: %v", clonedJob.Name, err)
+			}
 		}
 
 		cloneJobLock.Lock()
@@ -168,14 +266,14 @@ func (sc *PintaCache) Snapshot(jobCustomFieldsType reflect.Type) *info.ClusterIn
 		cloneJobLock.Unlock()
 	}
 
-	for _, value := range sc.Jobs {
+	for _, value := range jobs {
 		wg.Add(1)
 		go cloneJob(value)
 	}
 	wg.Wait()
 
-	klog.V(3).Infof("There are <%d> Jobs and <%d> Nodes in total for scheduling.",
-		len(snapshot.Jobs), len(snapshot.Nodes))
+	klog.V(3).Infof("There are <%d> Jobs, <%d> Nodes and <%d> Queues in total for scheduling.",
+		len(snapshot.Jobs), len(snapshot.Nodes), len(snapshot.Queues))
 
 	return snapshot
 }
@@ -194,3 +292,30 @@ func (sc *PintaCache) VCClient() volcanoclientset.Interface {
 func (sc *PintaCache) PintaClient() clientset.Interface {
 	return sc.pintaClient
 }
+
+// UpdateJobStatus commits the status of the job to the API server
+func (sc *PintaCache) UpdateJobStatus(job *pintav1.PintaJob) error {
+	return sc.JobInfoUpdater.UpdateJobStatus(job)
+}
+
+// RecordJobEvent records an event on the job as a Kubernetes Event
+func (sc *PintaCache) RecordJobEvent(job *pintav1.PintaJob, eventType, reason, message string) {
+	sc.Recorder.Event(job, eventType, reason, message)
+}
+
+// ReportProgress records the progress reported by the job
+func (sc *PintaCache) ReportProgress(jobID info.JobID, progress info.JobProgress) error {
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	if _, found := sc.Jobs[jobID]; !found {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	sc.progress[jobID] = &progress
+	sc.notify()
+	return nil
+}
+
+func (sc *PintaCache) JobStateStore() JobStateStore {
+	return sc.jobStateStore
+}
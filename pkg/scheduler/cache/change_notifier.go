@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	v1 "k8s.io/api/core/v1"
+	"reflect"
+)
+
+// changeNotifier signals changes of a cache. Signals are coalesced, so a receiver that falls behind sees
+// a single signal for all the changes since it last received one.
+type changeNotifier struct {
+	changes chan struct{}
+}
+
+func newChangeNotifier() changeNotifier {
+	return changeNotifier{changes: make(chan struct{}, 1)}
+}
+
+// Changes returns the channel the changes of the cache are signaled on
+func (cn changeNotifier) Changes() <-chan struct{} {
+	return cn.changes
+}
+
+func (cn changeNotifier) notify() {
+	select {
+	case cn.changes <- struct{}{}:
+	default:
+	}
+}
+
+// jobChanged returns whether the update changes what the scheduler sees of the job. Status updates that
+// leave the job schedulable, such as the ones the scheduler commits itself, do not count.
+func jobChanged(oldJob, newJob *pintav1.PintaJob) bool {
+	return schedulable(oldJob) != schedulable(newJob) ||
+		!reflect.DeepEqual(oldJob.Spec, newJob.Spec) ||
+		!reflect.DeepEqual(oldJob.Annotations, newJob.Annotations)
+}
+
+// nodeChanged returns whether the update changes what the scheduler sees of the node. Heartbeats of the
+// kubelet do not count.
+func nodeChanged(oldNode, newNode *v1.Node) bool {
+	oldNodeInfo, newNodeInfo := info.NewNodeInfo(oldNode), info.NewNodeInfo(newNode)
+	return oldNodeInfo.Ready() != newNodeInfo.Ready() ||
+		oldNodeInfo.Type != newNodeInfo.Type ||
+		!reflect.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) ||
+		!reflect.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable)
+}
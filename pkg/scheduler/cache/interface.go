@@ -17,6 +17,7 @@ limitations under the License.
 package cache
 
 import (
+	"errors"
 	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
 	v1 "k8s.io/api/core/v1"
 	"reflect"
@@ -48,10 +49,30 @@ type Cache interface {
 
 	// PintaClient returns the Pinta clientSet
 	PintaClient() clientset.Interface
+
+	// UpdateJobStatus commits the status of the job
+	UpdateJobStatus(job *pintav1.PintaJob) error
+
+	// RecordJobEvent records an event of the given type on the job
+	RecordJobEvent(job *pintav1.PintaJob, eventType, reason, message string)
+
+	// ReportProgress records the progress reported by the job. It returns ErrJobNotFound if the job is
+	// not in the cache.
+	ReportProgress(jobID info.JobID, progress info.JobProgress) error
+
+	// JobStateStore returns the store of the state policies keep for each job
+	JobStateStore() JobStateStore
+
+	// Changes returns a channel that is signaled when jobs, nodes, queues or PriorityClasses change, or
+	// jobs report progress. Signals are coalesced, so a receiver sees at least one signal after any change.
+	Changes() <-chan struct{}
 }
 
+// ErrJobNotFound is returned for jobs that are not in the cache
+var ErrJobNotFound = errors.New("job not found")
+
 type JobInfoUpdater interface {
 	UpdateJobNodeType(nodeType string) error
 	UpdateJobResourceRequirements(rl v1.ResourceList) error
-	UpdateJobStatus(status pintav1.PintaJobStatus) error
+	UpdateJobStatus(job *pintav1.PintaJob) error
 }
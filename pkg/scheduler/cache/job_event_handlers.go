@@ -15,13 +15,18 @@ func getJobID(job *pintav1.PintaJob) info.JobID {
 	return info.JobID(fmt.Sprintf("%s/%s", job.Namespace, job.Name))
 }
 
-// Assumes that lock is already acquired.
-func (sc *PintaCache) addJob(job *pintav1.PintaJob) error {
+// schedulable returns whether the job has been accepted by the controller and not yet completed.
+func schedulable(job *pintav1.PintaJob) bool {
 	var lastPintaJobStatus pintav1.PintaJobStatus
 	if len(job.Status) > 0 {
 		lastPintaJobStatus = job.Status[0]
 	}
-	if lastPintaJobStatus.State == "" || lastPintaJobStatus.State == pintav1.Completed {
+	return lastPintaJobStatus.State != "" && lastPintaJobStatus.State != pintav1.Completed
+}
+
+// Assumes that lock is already acquired.
+func (sc *PintaCache) addJob(job *pintav1.PintaJob) error {
+	if !schedulable(job) {
 		return nil
 	}
 
@@ -72,6 +77,9 @@ func (sc *PintaCache) AddJob(obj interface{}) {
 		return
 	}
 	klog.V(3).Infof("Added job <%s/%v> into cache.", job.Namespace, job.Name)
+	if schedulable(job) {
+		sc.notify()
+	}
 }
 
 // UpdateJob update job to scheduler cache
@@ -97,6 +105,9 @@ func (sc *PintaCache) UpdateJob(oldObj, newObj interface{}) {
 	}
 
 	klog.V(3).Infof("Updated job <%s/%v> in cache.", oldJob.Namespace, oldJob.Name)
+	if jobChanged(oldJob, newJob) {
+		sc.notify()
+	}
 }
 
 // DeleteJob delete job from scheduler cache
@@ -125,8 +136,12 @@ func (sc *PintaCache) DeleteJob(obj interface{}) {
 		klog.Errorf("Failed to delete job %v from cache: %v", job.Name, err)
 		return
 	}
+	delete(sc.progress, getJobID(job))
 
 	klog.V(3).Infof("Deleted job <%s/%v> from cache.", job.Namespace, job.Name)
+	if schedulable(job) {
+		sc.notify()
+	}
 }
 
 func getController(obj interface{}) types.UID {
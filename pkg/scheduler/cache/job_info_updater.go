@@ -1,9 +1,11 @@
 package cache
 
 import (
+	"context"
 	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
 	clientset "github.com/qed-usc/pinta-scheduler/pkg/generated/clientset/versioned"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type defaultJobInfoUpdater struct {
@@ -18,6 +20,7 @@ func (jiu *defaultJobInfoUpdater) UpdateJobResourceRequirements(rl v1.ResourceLi
 	panic("implement me")
 }
 
-func (jiu *defaultJobInfoUpdater) UpdateJobStatus(status pintav1.PintaJobStatus) error {
-	panic("implement me")
+func (jiu *defaultJobInfoUpdater) UpdateJobStatus(job *pintav1.PintaJob) error {
+	_, err := jiu.pintaClient.PintaV1().PintaJobs(job.Namespace).UpdateStatus(context.TODO(), job, metav1.UpdateOptions{})
+	return err
 }
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+)
+
+// JobStateStore keeps the state each policy records for each job between sessions
+type JobStateStore interface {
+	// Get returns the state the policy saved for the job, and whether there is one. It returns an error
+	// if the saved states cannot be read, in which case states must not be set or flushed, lest they
+	// overwrite the saved ones.
+	Get(jobID info.JobID, policy string) (string, bool, error)
+
+	// Set saves the state of the policy for the job
+	Set(jobID info.JobID, policy string, state string)
+
+	// Flush persists the saved states. States of jobs not in jobIDs are dropped.
+	Flush(jobIDs []info.JobID) error
+}
+
+type jobStates map[info.JobID]map[string]string
+
+func (js jobStates) get(jobID info.JobID, policy string) (string, bool) {
+	state, found := js[jobID][policy]
+	return state, found
+}
+
+// set returns whether the state has changed
+func (js jobStates) set(jobID info.JobID, policy string, state string) bool {
+	if old, found := js[jobID][policy]; found && old == state {
+		return false
+	}
+	if js[jobID] == nil {
+		js[jobID] = make(map[string]string)
+	}
+	js[jobID][policy] = state
+	return true
+}
+
+// prune returns whether any state has been dropped
+func (js jobStates) prune(jobIDs []info.JobID) bool {
+	keep := make(map[info.JobID]bool, len(jobIDs))
+	for _, jobID := range jobIDs {
+		keep[jobID] = true
+	}
+	pruned := false
+	for jobID := range js {
+		if !keep[jobID] {
+			delete(js, jobID)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// MemoryJobStateStore is a JobStateStore that lives in memory. States survive across sessions but not
+// restarts of the scheduler.
+type MemoryJobStateStore struct {
+	sync.Mutex
+
+	states jobStates
+}
+
+func NewMemoryJobStateStore() *MemoryJobStateStore {
+	return &MemoryJobStateStore{
+		states: make(jobStates),
+	}
+}
+
+func (s *MemoryJobStateStore) Get(jobID info.JobID, policy string) (string, bool, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	state, found := s.states.get(jobID, policy)
+	return state, found, nil
+}
+
+func (s *MemoryJobStateStore) Set(jobID info.JobID, policy string, state string) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	s.states.set(jobID, policy, state)
+}
+
+func (s *MemoryJobStateStore) Flush(jobIDs []info.JobID) error {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	s.states.prune(jobIDs)
+	return nil
+}
+
+// jobStatesKey is the key of the ConfigMap data the job states are kept in
+const jobStatesKey = "jobs.yaml"
+
+// ConfigMapJobStateStore is a JobStateStore that persists the states in a ConfigMap, so that they
+// survive restarts of the scheduler. The ConfigMap is read on first use and written on Flush when the
+// states have changed. It is created if it does not exist.
+type ConfigMapJobStateStore struct {
+	sync.Mutex
+
+	client    kubernetes.Interface
+	namespace string
+	name      string
+
+	states jobStates
+	loaded bool
+	dirty  bool
+}
+
+// NewConfigMapJobStateStore returns a store backed by the ConfigMap given as "namespace/name"
+func NewConfigMapJobStateStore(client kubernetes.Interface, configMap string) (*ConfigMapJobStateStore, error) {
+	parts := strings.Split(configMap, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid ConfigMap %q, expected namespace/name", configMap)
+	}
+	return &ConfigMapJobStateStore{
+		client:    client,
+		namespace: parts[0],
+		name:      parts[1],
+		states:    make(jobStates),
+	}, nil
+}
+
+func (s *ConfigMapJobStateStore) Get(jobID info.JobID, policy string) (string, bool, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if err := s.load(); err != nil {
+		return "", false, err
+	}
+	state, found := s.states.get(jobID, policy)
+	return state, found, nil
+}
+
+func (s *ConfigMapJobStateStore) Set(jobID info.JobID, policy string, state string) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if s.states.set(jobID, policy, state) {
+		s.dirty = true
+	}
+}
+
+func (s *ConfigMapJobStateStore) Flush(jobIDs []info.JobID) error {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	// Never overwrite the persisted states with the ones of this run only
+	if err := s.load(); err != nil {
+		return err
+	}
+	if s.states.prune(jobIDs) {
+		s.dirty = true
+	}
+	if !s.dirty {
+		return nil
+	}
+
+	data, err := yaml.Marshal(s.states)
+	if err != nil {
+		return err
+	}
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(context.TODO(), s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+			},
+			Data: map[string]string{jobStatesKey: string(data)},
+		}
+		_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+	} else if err == nil {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[jobStatesKey] = string(data)
+		_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write job states to ConfigMap %s/%s: %v", s.namespace, s.name, err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// load reads the states from the ConfigMap once. States set before are kept on top of the loaded ones.
+// Assumes that lock is already acquired.
+func (s *ConfigMapJobStateStore) load() error {
+	if s.loaded {
+		return nil
+	}
+
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to read job states from ConfigMap %s/%s: %v", s.namespace, s.name, err)
+	}
+	loaded := make(jobStates)
+	if err == nil {
+		if err := yaml.Unmarshal([]byte(cm.Data[jobStatesKey]), &loaded); err != nil {
+			return fmt.Errorf("failed to parse job states in ConfigMap %s/%s: %v", s.namespace, s.name, err)
+		}
+	}
+	for jobID, policyStates := range s.states {
+		for policy, state := range policyStates {
+			loaded.set(jobID, policy, state)
+		}
+	}
+	s.states = loaded
+	s.loaded = true
+	return nil
+}
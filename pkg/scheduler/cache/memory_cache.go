@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	clientset "github.com/qed-usc/pinta-scheduler/pkg/generated/clientset/versioned"
+	volcanoclientset "volcano.sh/volcano/pkg/client/clientset/versioned"
+)
+
+// Fixture is the file format of the nodes, jobs, queues and PriorityClasses loaded into a MemoryCache.
+// It can be written in either YAML or JSON.
+type Fixture struct {
+	Nodes           []v1.Node                    `json:"nodes,omitempty"`
+	Jobs            []pintav1.PintaJob           `json:"jobs,omitempty"`
+	Queues          []pintav1.PintaQueue         `json:"queues,omitempty"`
+	PriorityClasses []schedulingv1.PriorityClass `json:"priorityClasses,omitempty"`
+}
+
+// MemoryCache is a Cache that lives entirely in memory, without an API server behind it. Job status
+// updates are applied to the cached jobs and recorded in the StatusSink, and job events are recorded in
+// Events.
+type MemoryCache struct {
+	sync.Mutex
+	changeNotifier
+
+	StatusSink *JobStatusSink
+	JobStates  *MemoryJobStateStore
+
+	Jobs     map[info.JobID]*info.JobInfo
+	Nodes    map[string]*info.NodeInfo
+	Queues   map[string]*info.QueueInfo
+	Progress map[info.JobID]*info.JobProgress
+
+	PriorityClasses map[string]*schedulingv1.PriorityClass
+
+	Events []JobEvent
+}
+
+// JobEvent is an event recorded on a job
+type JobEvent struct {
+	Job     info.JobID
+	Type    string
+	Reason  string
+	Message string
+}
+
+// NewMemoryCache returns an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		changeNotifier: newChangeNotifier(),
+
+		StatusSink: NewJobStatusSink(),
+		JobStates:  NewMemoryJobStateStore(),
+		Jobs:       make(map[info.JobID]*info.JobInfo),
+		Nodes:      make(map[string]*info.NodeInfo),
+		Queues:     make(map[string]*info.QueueInfo),
+		Progress:   make(map[info.JobID]*info.JobProgress),
+
+		PriorityClasses: make(map[string]*schedulingv1.PriorityClass),
+	}
+}
+
+// NewMemoryCacheFromFixtures returns a MemoryCache loaded with the given fixture files
+func NewMemoryCacheFromFixtures(paths ...string) (*MemoryCache, error) {
+	mc := NewMemoryCache()
+	for _, path := range paths {
+		if err := mc.LoadFixture(path); err != nil {
+			return nil, err
+		}
+	}
+	return mc, nil
+}
+
+// LoadFixture adds the nodes, jobs, queues and PriorityClasses in the fixture file to the cache
+func (mc *MemoryCache) LoadFixture(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var fixture Fixture
+	if err := yaml.Unmarshal(content, &fixture); err != nil {
+		return fmt.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+
+	for i := range fixture.Nodes {
+		mc.AddNode(&fixture.Nodes[i])
+	}
+	for i := range fixture.Jobs {
+		mc.AddJob(&fixture.Jobs[i])
+	}
+	for i := range fixture.Queues {
+		mc.AddQueue(&fixture.Queues[i])
+	}
+	for i := range fixture.PriorityClasses {
+		mc.AddPriorityClass(&fixture.PriorityClasses[i])
+	}
+	return nil
+}
+
+// AddNode adds or replaces the node in the cache
+func (mc *MemoryCache) AddNode(node *v1.Node) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	mc.Nodes[node.Name] = info.NewNodeInfo(node)
+	mc.notify()
+}
+
+// DeleteNode deletes the node from the cache
+func (mc *MemoryCache) DeleteNode(name string) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	delete(mc.Nodes, name)
+	mc.notify()
+}
+
+// AddJob adds or replaces the job in the cache. Jobs that are not schedulable are ignored, the same way
+// PintaCache ignores them.
+func (mc *MemoryCache) AddJob(job *pintav1.PintaJob) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	mc.addJob(job)
+	mc.notify()
+}
+
+// Assumes that lock is already acquired.
+func (mc *MemoryCache) addJob(job *pintav1.PintaJob) {
+	jobID := getJobID(job)
+	if !schedulable(job) {
+		delete(mc.Jobs, jobID)
+		return
+	}
+	mc.Jobs[jobID] = info.NewJobInfo(jobID, job)
+}
+
+// DeleteJob deletes the job from the cache
+func (mc *MemoryCache) DeleteJob(job *pintav1.PintaJob) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	delete(mc.Jobs, getJobID(job))
+	delete(mc.Progress, getJobID(job))
+	mc.notify()
+}
+
+// AddQueue adds or replaces the queue in the cache
+func (mc *MemoryCache) AddQueue(queue *pintav1.PintaQueue) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	mc.Queues[queue.Name] = info.NewQueueInfo(queue)
+	mc.notify()
+}
+
+// DeleteQueue deletes the queue from the cache
+func (mc *MemoryCache) DeleteQueue(name string) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	delete(mc.Queues, name)
+	mc.notify()
+}
+
+// AddPriorityClass adds or replaces the PriorityClass in the cache
+func (mc *MemoryCache) AddPriorityClass(priorityClass *schedulingv1.PriorityClass) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	mc.PriorityClasses[priorityClass.Name] = priorityClass
+	mc.notify()
+}
+
+func (mc *MemoryCache) Run(stopCh <-chan struct{}) {}
+
+func (mc *MemoryCache) WaitForCacheSync(stopCh <-chan struct{}) bool {
+	return true
+}
+
+func (mc *MemoryCache) Snapshot(jobCustomFieldsType reflect.Type) *info.ClusterInfo {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	return snapshot(mc.Jobs, mc.Nodes, mc.Queues, mc.PriorityClasses, mc.Progress, jobCustomFieldsType)
+}
+
+// Client returns nil as there is no API server behind the cache
+func (mc *MemoryCache) Client() kubernetes.Interface {
+	return nil
+}
+
+// VCClient returns nil as there is no API server behind the cache
+func (mc *MemoryCache) VCClient() volcanoclientset.Interface {
+	return nil
+}
+
+// PintaClient returns nil as there is no API server behind the cache
+func (mc *MemoryCache) PintaClient() clientset.Interface {
+	return nil
+}
+
+// UpdateJobStatus records the job in the StatusSink, and applies the new status to the cached job
+func (mc *MemoryCache) UpdateJobStatus(job *pintav1.PintaJob) error {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	jobID := getJobID(job)
+	if _, found := mc.Jobs[jobID]; !found {
+		return fmt.Errorf("job <%s> does not exist", jobID)
+	}
+
+	mc.StatusSink.Record(jobID, job)
+	mc.addJob(job.DeepCopy())
+	return nil
+}
+
+// RecordJobEvent appends the event to Events
+func (mc *MemoryCache) RecordJobEvent(job *pintav1.PintaJob, eventType, reason, message string) {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	mc.Events = append(mc.Events, JobEvent{
+		Job:     getJobID(job),
+		Type:    eventType,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// ReportProgress records the progress reported by the job
+func (mc *MemoryCache) ReportProgress(jobID info.JobID, progress info.JobProgress) error {
+	mc.Mutex.Lock()
+	defer mc.Mutex.Unlock()
+
+	if _, found := mc.Jobs[jobID]; !found {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	mc.Progress[jobID] = &progress
+	mc.notify()
+	return nil
+}
+
+func (mc *MemoryCache) JobStateStore() JobStateStore {
+	return mc.JobStates
+}
+
+// JobStatusSink records the job status updates committed to a MemoryCache
+type JobStatusSink struct {
+	sync.Mutex
+
+	updates map[info.JobID][]*pintav1.PintaJob
+}
+
+func NewJobStatusSink() *JobStatusSink {
+	return &JobStatusSink{
+		updates: make(map[info.JobID][]*pintav1.PintaJob),
+	}
+}
+
+// Record appends a copy of the job to the updates of the job
+func (s *JobStatusSink) Record(jobID info.JobID, job *pintav1.PintaJob) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	s.updates[jobID] = append(s.updates[jobID], job.DeepCopy())
+}
+
+// Updates returns the recorded updates of the job in commit order
+func (s *JobStatusSink) Updates(jobID info.JobID) []*pintav1.PintaJob {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	return s.updates[jobID]
+}
+
+// LastStatus returns the latest committed status of the job, and whether the job has been updated at all
+func (s *JobStatusSink) LastStatus(jobID info.JobID) (pintav1.PintaJobStatus, bool) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	updates := s.updates[jobID]
+	if len(updates) == 0 || len(updates[len(updates)-1].Status) == 0 {
+		return pintav1.PintaJobStatus{}, false
+	}
+	return updates[len(updates)-1].Status[0], true
+}
+
+// Len returns the total number of recorded updates
+func (s *JobStatusSink) Len() int {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	n := 0
+	for _, updates := range s.updates {
+		n += len(updates)
+	}
+	return n
+}
+
+// Reset drops all recorded updates
+func (s *JobStatusSink) Reset() {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	s.updates = make(map[info.JobID][]*pintav1.PintaJob)
+}
@@ -53,6 +53,7 @@ func (sc *PintaCache) AddNode(obj interface{}) {
 		klog.Errorf("Failed to add node %s into cache: %v", node.Name, err)
 		return
 	}
+	sc.notify()
 }
 
 // UpdateNode update node to scheduler cache
@@ -76,6 +77,9 @@ func (sc *PintaCache) UpdateNode(oldObj, newObj interface{}) {
 		klog.Errorf("Failed to update node %v in cache: %v", oldNode.Name, err)
 		return
 	}
+	if nodeChanged(oldNode, newNode) {
+		sc.notify()
+	}
 }
 
 // DeleteNode delete node from scheduler cache
@@ -104,4 +108,5 @@ func (sc *PintaCache) DeleteNode(obj interface{}) {
 		klog.Errorf("Failed to delete node %s from cache: %v", node.Name, err)
 		return
 	}
+	sc.notify()
 }
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"fmt"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// Assumes that lock is already acquired.
+func (sc *PintaCache) addPriorityClass(priorityClass *schedulingv1.PriorityClass) error {
+	sc.PriorityClasses[priorityClass.Name] = priorityClass
+	return nil
+}
+
+// Assumes that lock is already acquired.
+func (sc *PintaCache) deletePriorityClass(priorityClass *schedulingv1.PriorityClass) error {
+	if _, ok := sc.PriorityClasses[priorityClass.Name]; !ok {
+		return fmt.Errorf("PriorityClass <%s> does not exist", priorityClass.Name)
+	}
+	delete(sc.PriorityClasses, priorityClass.Name)
+	return nil
+}
+
+// AddPriorityClass add PriorityClass to scheduler cache
+func (sc *PintaCache) AddPriorityClass(obj interface{}) {
+	priorityClass, ok := obj.(*schedulingv1.PriorityClass)
+	if !ok {
+		klog.Errorf("Cannot convert to *schedulingv1.PriorityClass: %v", obj)
+		return
+	}
+
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	err := sc.addPriorityClass(priorityClass)
+	if err != nil {
+		klog.Errorf("Failed to add PriorityClass %s into cache: %v", priorityClass.Name, err)
+		return
+	}
+	sc.notify()
+}
+
+// UpdatePriorityClass update PriorityClass to scheduler cache
+func (sc *PintaCache) UpdatePriorityClass(oldObj, newObj interface{}) {
+	newPriorityClass, ok := newObj.(*schedulingv1.PriorityClass)
+	if !ok {
+		klog.Errorf("Cannot convert newObj to *schedulingv1.PriorityClass: %v", newObj)
+		return
+	}
+
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	err := sc.addPriorityClass(newPriorityClass)
+	if err != nil {
+		klog.Errorf("Failed to update PriorityClass %s in cache: %v", newPriorityClass.Name, err)
+		return
+	}
+	sc.notify()
+}
+
+// DeletePriorityClass delete PriorityClass from scheduler cache
+func (sc *PintaCache) DeletePriorityClass(obj interface{}) {
+	var priorityClass *schedulingv1.PriorityClass
+	switch t := obj.(type) {
+	case *schedulingv1.PriorityClass:
+		priorityClass = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		priorityClass, ok = t.Obj.(*schedulingv1.PriorityClass)
+		if !ok {
+			klog.Errorf("Cannot convert to *schedulingv1.PriorityClass: %v", t.Obj)
+			return
+		}
+	default:
+		klog.Errorf("Cannot convert to *schedulingv1.PriorityClass: %v", t)
+		return
+	}
+
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	err := sc.deletePriorityClass(priorityClass)
+	if err != nil {
+		klog.Errorf("Failed to delete PriorityClass %s from cache: %v", priorityClass.Name, err)
+		return
+	}
+	sc.notify()
+}
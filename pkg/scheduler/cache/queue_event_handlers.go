@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// Assumes that lock is already acquired.
+func (sc *PintaCache) addQueue(queue *pintav1.PintaQueue) error {
+	sc.Queues[queue.Name] = info.NewQueueInfo(queue)
+	return nil
+}
+
+// Assumes that lock is already acquired.
+func (sc *PintaCache) deleteQueue(queue *pintav1.PintaQueue) error {
+	if _, ok := sc.Queues[queue.Name]; !ok {
+		return fmt.Errorf("queue <%s> does not exist", queue.Name)
+	}
+	delete(sc.Queues, queue.Name)
+	return nil
+}
+
+// AddQueue add queue to scheduler cache
+func (sc *PintaCache) AddQueue(obj interface{}) {
+	queue, ok := obj.(*pintav1.PintaQueue)
+	if !ok {
+		klog.Errorf("Cannot convert to *pintav1.PintaQueue: %v", obj)
+		return
+	}
+
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	err := sc.addQueue(queue)
+	if err != nil {
+		klog.Errorf("Failed to add queue %s into cache: %v", queue.Name, err)
+		return
+	}
+	sc.notify()
+}
+
+// UpdateQueue update queue to scheduler cache
+func (sc *PintaCache) UpdateQueue(oldObj, newObj interface{}) {
+	newQueue, ok := newObj.(*pintav1.PintaQueue)
+	if !ok {
+		klog.Errorf("Cannot convert newObj to *pintav1.PintaQueue: %v", newObj)
+		return
+	}
+
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	err := sc.addQueue(newQueue)
+	if err != nil {
+		klog.Errorf("Failed to update queue %s in cache: %v", newQueue.Name, err)
+		return
+	}
+	sc.notify()
+}
+
+// DeleteQueue delete queue from scheduler cache
+func (sc *PintaCache) DeleteQueue(obj interface{}) {
+	var queue *pintav1.PintaQueue
+	switch t := obj.(type) {
+	case *pintav1.PintaQueue:
+		queue = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		queue, ok = t.Obj.(*pintav1.PintaQueue)
+		if !ok {
+			klog.Errorf("Cannot convert to *pintav1.PintaQueue: %v", t.Obj)
+			return
+		}
+	default:
+		klog.Errorf("Cannot convert to *pintav1.PintaQueue: %v", t)
+		return
+	}
+
+	sc.Mutex.Lock()
+	defer sc.Mutex.Unlock()
+
+	err := sc.deleteQueue(queue)
+	if err != nil {
+		klog.Errorf("Failed to delete queue %s from cache: %v", queue.Name, err)
+		return
+	}
+	sc.notify()
+}
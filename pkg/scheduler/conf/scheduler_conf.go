@@ -18,14 +18,28 @@ package conf
 
 // SchedulerConfiguration defines the configuration of scheduler.
 type SchedulerConfiguration struct {
-	// policies defines the policies list of scheduler in order
+	// Policy defines a single policy of scheduler; it is equivalent to a pipeline of one policy
+	// and cannot be specified together with Policies
 	Policy string `yaml:"policy"`
-	// Configurations is configuration for policies
+	// Configurations is configuration for Policy
 	Configuration Configuration `yaml:"configuration"`
+	// Policies defines the policies pipeline of scheduler, executed in order in each cycle
+	Policies []PolicyOption `yaml:"policies"`
+	// ShadowPolicies are executed each cycle on their own copy of the snapshot, and compared with the
+	// policies pipeline without committing anything
+	ShadowPolicies []PolicyOption `yaml:"shadowPolicies"`
+}
+
+// PolicyOption is a policy in the pipeline
+type PolicyOption struct {
+	// Name is the name of the policy
+	Name string `yaml:"name" json:"name"`
+	// Configuration is configuration of the policy
+	Configuration Configuration `yaml:"configuration" json:"configuration"`
 }
 
 // Configuration is configuration of policy
 type Configuration struct {
 	// Arguments defines the different arguments that can be given to specified policy
-	Arguments map[string]string `yaml:"arguments"`
+	Arguments map[string]string `yaml:"arguments" json:"arguments,omitempty"`
 }
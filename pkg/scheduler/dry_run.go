@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"k8s.io/klog"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+// DryRunPath is the path the result of the latest dry-run cycle is served at
+const DryRunPath = "/dry-run"
+
+// dryRunReporter logs the results of dry-run cycles, appends them to a file as JSON lines, and keeps the
+// latest one to serve over HTTP
+type dryRunReporter struct {
+	sync.Mutex
+
+	// file is nil if the results are not written to a file
+	file   *os.File
+	latest *session.DryRunResult
+}
+
+func newDryRunReporter(path string) (*dryRunReporter, error) {
+	reporter := &dryRunReporter{}
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open dry-run file: %v", err)
+		}
+		reporter.file = file
+	}
+	return reporter, nil
+}
+
+func (r *dryRunReporter) Report(result *session.DryRunResult) {
+	for _, diff := range result.Changes {
+		klog.Infof("Dry run: job <%s> in state %q would go from %d masters and %d replicas to %d masters and %d replicas: %s",
+			diff.Job, diff.State, diff.NumMasters, diff.NumReplicas, diff.ProposedNumMasters, diff.ProposedNumReplicas, diff.Message)
+	}
+	klog.V(3).Infof("Dry run of session %v: %d jobs would change, %d would stay the same",
+		result.Session, len(result.Changes), result.NumUnchanged)
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.latest = result
+	if r.file == nil {
+		return
+	}
+	line, err := json.Marshal(result)
+	if err != nil {
+		klog.Errorf("Cannot encode result of dry run: %v", err)
+		return
+	}
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		klog.Errorf("Cannot write result of dry run to %s: %v", r.file.Name(), err)
+	}
+}
+
+// ServeHTTP responds to GET with the result of the latest dry-run cycle as JSON
+func (r *dryRunReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Mutex.Lock()
+	latest := r.latest
+	r.Mutex.Unlock()
+
+	if latest == nil {
+		http.Error(w, "no dry-run cycle has run yet", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(latest); err != nil {
+		klog.Errorf("Cannot serve result of dry run: %v", err)
+	}
+}
+
+// EnableDryRun makes the scheduler compute allocations without committing them. The result of each cycle
+// is logged, appended to the file at path as a JSON line unless path is empty, and the latest one is
+// served by DryRunHandler.
+func (pc *Scheduler) EnableDryRun(path string) error {
+	reporter, err := newDryRunReporter(path)
+	if err != nil {
+		return err
+	}
+	pc.dryRun = reporter
+	return nil
+}
+
+// DryRunHandler returns the HTTP handler that serves the result of the latest dry-run cycle, or nil if
+// dry-run mode is not enabled
+func (pc *Scheduler) DryRunHandler() http.Handler {
+	if pc.dryRun == nil {
+		return nil
+	}
+	return pc.dryRun
+}
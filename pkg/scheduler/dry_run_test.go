@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+func TestDryRunReporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dry-run")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dry-run.jsonl")
+	reporter, err := newDryRunReporter(path)
+	if err != nil {
+		t.Fatalf("Failed to build reporter: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	reporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, DryRunPath, nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d before the first cycle, got %d", http.StatusNotFound, recorder.Code)
+	}
+
+	for _, numReplicas := range []int32{1, 2} {
+		reporter.Report(&session.DryRunResult{
+			Changes: []session.JobDiff{{Job: "default/j1", ProposedNumReplicas: numReplicas}},
+		})
+	}
+
+	// Every result is appended to the file
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read dry-run file: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("Expected 2 lines in the dry-run file, got %d", len(lines))
+	}
+
+	// The latest result is served
+	recorder = httptest.NewRecorder()
+	reporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, DryRunPath, nil))
+	var result session.DryRunResult
+	if err := json.NewDecoder(recorder.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode dry-run result: %v", err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].ProposedNumReplicas != 2 {
+		t.Errorf("Expected the latest result to be served, got %+v", result)
+	}
+}
@@ -0,0 +1,442 @@
+package capacity
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"math"
+	"reflect"
+	"sort"
+)
+
+type JobCustomFields = session.RoleCustomFields
+
+// Policy allocates jobs within the capacity of their PintaQueues. A job runs within the guarantee of its
+// queue if neither the queue nor any of its ancestors goes over the resources listed in their guarantees,
+// and the queue has a guarantee at all. Such jobs are started first, first come first served, reclaiming
+// resources from the youngest running jobs of queues that borrow beyond their guarantee if the cluster is
+// full. The rest of the cluster is lent to the other waiting jobs one at a time, starting with the queue
+// of the lowest dominant share divided by its weight, as long as no queue goes over its guarantee plus
+// its borrowing limit. A queue never runs more than its max # running jobs.
+//
+// Jobs without a queue, or whose queue does not exist, have no guarantee and are not limited. Running
+// jobs keep their resources unless reclaimed, or preempted by waiting jobs of higher priority, which go
+// first within each phase. Jobs that cannot be preempted are not reclaimed either.
+type Policy struct{}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	return &Policy{}, nil
+}
+
+func (c *Policy) Name() string {
+	return "capacity"
+}
+
+func (c *Policy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*JobCustomFields)(nil))
+}
+
+func (c *Policy) Initialize() {}
+
+// queue tracks what the jobs of a PintaQueue and its descendants are allocated
+type queue struct {
+	name   string
+	weight int32
+	parent *queue
+
+	// guarantee and limit only have the resources listed in the PintaQueue; limit is nil if unlimited
+	guarantee map[v1.ResourceName]float64
+	limit     map[v1.ResourceName]float64
+
+	maxRunningJobs int32
+
+	allocated  *info.Resource
+	numRunning int32
+	waiting    []*info.JobInfo
+}
+
+func (c *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin Capacity")
+	defer klog.V(3).Infof("End Capacity")
+
+	total := info.EmptyResource()
+	for _, node := range ssn.Nodes {
+		total.Add(node.Allocatable)
+	}
+	queues := buildQueues(ssn)
+	// Jobs without a queue share an unlimited queue without a guarantee
+	defaultQueue := &queue{weight: 1, allocated: info.EmptyResource()}
+	queueOf := func(job *info.JobInfo) *queue {
+		if q, found := queues[job.Queue]; found {
+			return q
+		}
+		return defaultQueue
+	}
+
+	// Running jobs keep their resources
+	pool := session.NewNodePool(ssn)
+	var running, waiting []*info.JobInfo
+	allocated := make(map[info.JobID]*info.Resource)
+	for _, job := range ssn.Jobs {
+		if job.NumMasters+job.NumReplicas == 0 {
+			waiting = append(waiting, job)
+			continue
+		}
+		if !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+			klog.Warningf("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+		}
+		allocated[job.UID] = pool.Allocated(job)
+		queueOf(job).charge(allocated[job.UID])
+		running = append(running, job)
+	}
+	sort.Slice(waiting, func(i, j int) bool {
+		return session.JobLess(waiting[i], waiting[j])
+	})
+
+	// preempt makes room for the job by stopping running jobs of lower priority
+	preempt := func(job *info.JobInfo, numMasters, numReplicas int32) bool {
+		victims := pool.Preempt(job, numMasters, numReplicas, running)
+		if victims == nil {
+			return false
+		}
+		for _, victim := range victims {
+			klog.V(4).Infof("Preempting job <%s/%s> for job <%s/%s> of higher priority",
+				victim.Namespace, victim.Name, job.Namespace, job.Name)
+			queueOf(victim).refund(allocated[victim.UID])
+			victim.NumMasters = 0
+			victim.NumReplicas = 0
+			ssn.Explainf(victim, session.ReasonPreempted, "preempted by job %s/%s of priority %d",
+				job.Namespace, job.Name, job.Priority)
+		}
+		running = session.StillRunning(running)
+		return true
+	}
+
+	// Start the jobs within guarantee, reclaiming borrowed resources if needed
+	var borrowing []*info.JobInfo
+	for _, job := range waiting {
+		q := queueOf(job)
+		numMasters, numReplicas := session.RequestedRoles(job)
+		demand, ok := demandOf(ssn, job, numMasters, numReplicas)
+		if !ok {
+			continue
+		}
+		if !q.withinGuarantee(demand) || !q.belowMaxRunningJobs() {
+			borrowing = append(borrowing, job)
+			continue
+		}
+
+		if !pool.AllocateJob(job, numMasters, numReplicas) {
+			var reclaimed []*info.JobInfo
+			pool, running, reclaimed = reclaim(pool, running, job, numMasters, numReplicas, q, queueOf)
+			for _, victim := range reclaimed {
+				klog.V(4).Infof("Reclaimed job <%s/%s> of queue %s for job <%s/%s> of queue %s",
+					victim.Namespace, victim.Name, victim.Queue, job.Namespace, job.Name, job.Queue)
+				victim.NumMasters = 0
+				victim.NumReplicas = 0
+				ssn.Explainf(victim, session.ReasonPreempted, "resources borrowed by queue %s reclaimed by job %s/%s",
+					victim.Queue, job.Namespace, job.Name)
+			}
+			if reclaimed == nil && !preempt(job, numMasters, numReplicas) {
+				borrowing = append(borrowing, job)
+				continue
+			}
+		}
+		job.NumMasters = numMasters
+		job.NumReplicas = numReplicas
+		allocated[job.UID] = pool.Allocated(job)
+		q.charge(allocated[job.UID])
+		running = append(running, job)
+		klog.V(4).Infof("Started job <%s/%s> within the guarantee of queue %s", job.Namespace, job.Name, job.Queue)
+		ssn.Explainf(job, session.ReasonScheduled, "within the guarantee of %v", q)
+	}
+
+	// Lend what is left, lowest weighted dominant share first
+	for _, job := range borrowing {
+		q := queueOf(job)
+		q.waiting = append(q.waiting, job)
+	}
+	leaves := []*queue{defaultQueue}
+	for _, q := range queues {
+		leaves = append(leaves, q)
+	}
+	for {
+		var next *queue
+		minShare := math.MaxFloat64
+		for _, q := range leaves {
+			if len(q.waiting) == 0 {
+				continue
+			}
+			share := session.DominantShare(q.allocated, total) / float64(q.weight)
+			if next != nil && q.waiting[0].Priority != next.waiting[0].Priority {
+				if q.waiting[0].Priority > next.waiting[0].Priority {
+					next = q
+					minShare = share
+				}
+				continue
+			}
+			if next == nil || share < minShare || (share == minShare && q.name < next.name) {
+				next = q
+				minShare = share
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		job := next.waiting[0]
+		next.waiting = next.waiting[1:]
+		if !next.belowMaxRunningJobs() {
+			ssn.Explainf(job, session.ReasonQueueLimit, "%v at its max # running jobs", next)
+			continue
+		}
+		numMasters, numReplicas := session.RequestedRoles(job)
+		if !pool.AllocateJob(job, numMasters, numReplicas) {
+			demand, ok := demandOf(ssn, job, numMasters, numReplicas)
+			if ok && !next.withinLimit(demand) {
+				ssn.Explainf(job, session.ReasonQueueLimit, "%v at its limit", next)
+				continue
+			}
+			if !ok || !preempt(job, numMasters, numReplicas) {
+				ssn.Explainf(job, session.ReasonInsufficientResources, "%s", pool.Shortage(job, numMasters, numReplicas))
+				continue
+			}
+		} else if !next.withinLimit(pool.Allocated(job)) {
+			pool.Release(job)
+			ssn.Explainf(job, session.ReasonQueueLimit, "%v at its limit", next)
+			continue
+		}
+		job.NumMasters = numMasters
+		job.NumReplicas = numReplicas
+		allocated[job.UID] = pool.Allocated(job)
+		next.charge(allocated[job.UID])
+		running = append(running, job)
+		klog.V(4).Infof("Started job <%s/%s> of queue %s with weighted dominant share %v",
+			job.Namespace, job.Name, job.Queue, minShare)
+		ssn.Explainf(job, session.ReasonScheduled, "borrowing, %v at weighted dominant share %.2f", next, minShare)
+	}
+}
+
+func (c *Policy) UnInitialize() {}
+
+// buildQueues returns the queues of the session by name, linked to their parents. Parents that do not
+// exist or that would make a cycle are ignored.
+func buildQueues(ssn *session.Session) map[string]*queue {
+	queues := make(map[string]*queue, len(ssn.Queues))
+	for name, qi := range ssn.Queues {
+		q := &queue{
+			name:           name,
+			weight:         qi.Weight,
+			guarantee:      resourceQuantities(ssn, qi.Guarantee),
+			maxRunningJobs: qi.MaxRunningJobs,
+			allocated:      info.EmptyResource(),
+		}
+		if qi.BorrowingLimit != nil {
+			borrowingLimit := resourceQuantities(ssn, qi.BorrowingLimit)
+			q.limit = make(map[v1.ResourceName]float64, len(borrowingLimit))
+			for rn, quantity := range borrowingLimit {
+				q.limit[rn] = q.guarantee[rn] + quantity
+			}
+		}
+		queues[name] = q
+	}
+	for name, qi := range ssn.Queues {
+		if qi.Parent == "" {
+			continue
+		}
+		parent, found := queues[qi.Parent]
+		if !found {
+			klog.Warningf("Parent %s of queue %s does not exist", qi.Parent, name)
+			continue
+		}
+		cycle := false
+		for p := parent; p != nil; p = p.parent {
+			if p == queues[name] {
+				cycle = true
+				break
+			}
+		}
+		if cycle {
+			klog.Warningf("Parent %s of queue %s makes a cycle", qi.Parent, name)
+			continue
+		}
+		queues[name].parent = parent
+	}
+	return queues
+}
+
+// resourceQuantities returns the quantities of the resources listed in rl that shares are taken over
+func resourceQuantities(ssn *session.Session, rl v1.ResourceList) map[v1.ResourceName]float64 {
+	r, err := info.NewRoleResource(rl, ssn.NodeTypes, "")
+	if err != nil {
+		klog.Warningf("Failed to parse queue resources %v: %v", rl, err)
+		return nil
+	}
+	_, wholeNodes := rl[info.NodeResourceName]
+	quantities := make(map[v1.ResourceName]float64)
+	for _, rn := range session.ShareResourceNames {
+		if _, found := rl[rn]; found || wholeNodes {
+			quantities[rn] = r.Get(rn)
+		}
+	}
+	return quantities
+}
+
+// demandOf returns the resources the job would be allocated in an empty cluster, and whether it fits
+func demandOf(ssn *session.Session, job *info.JobInfo, numMasters, numReplicas int32) (*info.Resource, bool) {
+	pool := session.NewNodePool(ssn)
+	if !pool.AllocateJob(job, numMasters, numReplicas) {
+		return nil, false
+	}
+	return pool.Allocated(job), true
+}
+
+// reclaim releases running jobs of queues that borrow until the job fits in the pool, lowest priority
+// first and youngest first within a priority. It returns the new pool and running jobs, and the
+// reclaimed jobs, nil if the job does not fit.
+func reclaim(
+	pool *session.NodePool,
+	running []*info.JobInfo,
+	job *info.JobInfo,
+	numMasters, numReplicas int32,
+	q *queue,
+	queueOf func(*info.JobInfo) *queue,
+) (*session.NodePool, []*info.JobInfo, []*info.JobInfo) {
+	clone := pool.Clone()
+	// What the queues would be allocated after the reclaim
+	allocated := make(map[*queue]*info.Resource)
+	allocatedOf := func(p *queue) *info.Resource {
+		if _, found := allocated[p]; !found {
+			allocated[p] = p.allocated.Clone()
+		}
+		return allocated[p]
+	}
+	borrows := func(victimQueue *queue) bool {
+		for p := victimQueue; p != nil; p = p.parent {
+			if p.isBorrowing(allocatedOf(p)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	candidates := append([]*info.JobInfo(nil), running...)
+	sort.Slice(candidates, func(i, j int) bool {
+		return session.JobLess(candidates[j], candidates[i])
+	})
+
+	var reclaimed []*info.JobInfo
+	var releasedResources []*info.Resource
+	fits := false
+	for _, victim := range candidates {
+		victimQueue := queueOf(victim)
+		if victimQueue == q || !victim.Preemptible || !borrows(victimQueue) {
+			continue
+		}
+		released := clone.Allocated(victim)
+		clone.Release(victim)
+		for p := victimQueue; p != nil; p = p.parent {
+			allocatedOf(p).Sub(released)
+		}
+		reclaimed = append(reclaimed, victim)
+		releasedResources = append(releasedResources, released)
+		if clone.AllocateJob(job, numMasters, numReplicas) {
+			fits = true
+			break
+		}
+	}
+	if !fits {
+		return pool, running, nil
+	}
+
+	isReclaimed := make(map[*info.JobInfo]bool, len(reclaimed))
+	for i, victim := range reclaimed {
+		isReclaimed[victim] = true
+		queueOf(victim).refund(releasedResources[i])
+	}
+	remaining := make([]*info.JobInfo, 0, len(running)-len(reclaimed))
+	for _, r := range running {
+		if !isReclaimed[r] {
+			remaining = append(remaining, r)
+		}
+	}
+	return clone, remaining, reclaimed
+}
+
+// String names the queue in explanations
+func (q *queue) String() string {
+	if q.name == "" {
+		return "the default queue"
+	}
+	return "queue " + q.name
+}
+
+// charge adds the resources of a started job to the queue and its ancestors
+func (q *queue) charge(allocated *info.Resource) {
+	for p := q; p != nil; p = p.parent {
+		p.allocated.Add(allocated)
+		p.numRunning++
+	}
+}
+
+// refund removes the resources of a stopped job from the queue and its ancestors
+func (q *queue) refund(allocated *info.Resource) {
+	for p := q; p != nil; p = p.parent {
+		p.allocated.Sub(allocated)
+		p.numRunning--
+	}
+}
+
+// withinGuarantee returns whether the queue has a guarantee, and neither the queue nor any of its
+// ancestors goes over its guarantee with demand on top
+func (q *queue) withinGuarantee(demand *info.Resource) bool {
+	if len(q.guarantee) == 0 {
+		return false
+	}
+	for p := q; p != nil; p = p.parent {
+		if !within(p.allocated, demand, p.guarantee) {
+			return false
+		}
+	}
+	return true
+}
+
+// withinLimit returns whether neither the queue nor any of its ancestors goes over its guarantee plus its
+// borrowing limit with demand on top
+func (q *queue) withinLimit(demand *info.Resource) bool {
+	for p := q; p != nil; p = p.parent {
+		if p.limit != nil && !within(p.allocated, demand, p.limit) {
+			return false
+		}
+	}
+	return true
+}
+
+// belowMaxRunningJobs returns whether one more job of the queue can run
+func (q *queue) belowMaxRunningJobs() bool {
+	for p := q; p != nil; p = p.parent {
+		if p.maxRunningJobs > 0 && p.numRunning >= p.maxRunningJobs {
+			return false
+		}
+	}
+	return true
+}
+
+// isBorrowing returns whether the queue is allocated more than its guarantee
+func (q *queue) isBorrowing(allocated *info.Resource) bool {
+	if len(q.guarantee) == 0 {
+		return !allocated.IsEmpty()
+	}
+	return !within(allocated, info.EmptyResource(), q.guarantee)
+}
+
+// within returns whether allocated plus demand fits in the quantities of the resources that are listed
+func within(allocated, demand *info.Resource, quantities map[v1.ResourceName]float64) bool {
+	for rn, quantity := range quantities {
+		if allocated.Get(rn)+demand.Get(rn) > quantity {
+			return false
+		}
+	}
+	return true
+}
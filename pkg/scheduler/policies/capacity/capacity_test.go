@@ -0,0 +1,204 @@
+package capacity
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func buildQueueInfo(name string, spec pintav1.PintaQueueSpec) *info.QueueInfo {
+	return info.NewQueueInfo(&pintav1.PintaQueue{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	})
+}
+
+func cpu(quantity string) v1.ResourceList {
+	return v1.ResourceList{v1.ResourceCPU: resource.MustParse(quantity)}
+}
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+// nodeResources are the resources of each node
+var nodeResources = v1.ResourceList{
+	v1.ResourceCPU:    resource.MustParse("4"),
+	v1.ResourceMemory: resource.MustParse("16Gi"),
+}
+
+func TestPolicy_Execute(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name     string
+		numNodes int
+		queues   []*info.QueueInfo
+		jobs     []*info.JobInfo
+		expected map[info.JobID]int32
+	}{
+		{
+			name:     "guaranteed job reclaims borrowed resources",
+			numNodes: 2,
+			queues: []*info.QueueInfo{
+				buildQueueInfo("a", pintav1.PintaQueueSpec{Guarantee: cpu("4")}),
+				buildQueueInfo("b", pintav1.PintaQueueSpec{Guarantee: cpu("4")}),
+			},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("b1", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("b2", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("b3", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("b4", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a1", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+			},
+			expected: map[info.JobID]int32{"a1": 1, "b1": 1, "b2": 1, "b3": 1, "b4": 0},
+		},
+		{
+			name:     "guarantees in nodes",
+			numNodes: 4,
+			queues: []*info.QueueInfo{
+				buildQueueInfo("a", pintav1.PintaQueueSpec{Guarantee: v1.ResourceList{"node": resource.MustParse("2")}}),
+				buildQueueInfo("b", pintav1.PintaQueueSpec{Guarantee: v1.ResourceList{"node": resource.MustParse("2")}}),
+			},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("b1", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.Running(8), sessiontest.WithReplicaResources(cpu("2")),
+					sessiontest.WithCustomFields("numReplicas: 8")),
+				sessiontest.BuildJob("a1", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2")), sessiontest.WithCustomFields("numReplicas: 4")),
+			},
+			expected: map[info.JobID]int32{"a1": 4, "b1": 0},
+		},
+		{
+			name:     "borrowing is limited",
+			numNodes: 2,
+			queues: []*info.QueueInfo{
+				buildQueueInfo("a", pintav1.PintaQueueSpec{Guarantee: cpu("2"), BorrowingLimit: cpu("2")}),
+			},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("a1", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a2", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a3", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+			},
+			expected: map[info.JobID]int32{"a1": 1, "a2": 1, "a3": 0},
+		},
+		{
+			name:     "running jobs are capped",
+			numNodes: 2,
+			queues: []*info.QueueInfo{
+				buildQueueInfo("a", pintav1.PintaQueueSpec{Guarantee: cpu("8"), MaxRunningJobs: 1}),
+			},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("a1", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a2", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+			},
+			expected: map[info.JobID]int32{"a1": 1, "a2": 0},
+		},
+		{
+			name:     "guarantee of the parent bounds its children",
+			numNodes: 2,
+			queues: []*info.QueueInfo{
+				buildQueueInfo("p", pintav1.PintaQueueSpec{Guarantee: cpu("2")}),
+				buildQueueInfo("c1", pintav1.PintaQueueSpec{Parent: "p", Guarantee: cpu("2")}),
+				buildQueueInfo("c2", pintav1.PintaQueueSpec{Parent: "p", Guarantee: cpu("2")}),
+			},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("c1", customFieldsType,
+					sessiontest.InQueue("c1"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("o1", customFieldsType,
+					sessiontest.InQueue(""), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("o2", customFieldsType,
+					sessiontest.InQueue(""), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("o3", customFieldsType,
+					sessiontest.InQueue(""), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("c2", customFieldsType,
+					sessiontest.InQueue("c2"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+			},
+			expected: map[info.JobID]int32{"c1": 1, "c2": 0, "o1": 1, "o2": 1, "o3": 1},
+		},
+		{
+			name:     "borrowed resources are shared by weight",
+			numNodes: 2,
+			queues: []*info.QueueInfo{
+				buildQueueInfo("a", pintav1.PintaQueueSpec{Weight: 2}),
+				buildQueueInfo("b", pintav1.PintaQueueSpec{}),
+			},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("a1", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-8*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a2", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-7*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a3", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-6*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("a4", customFieldsType,
+					sessiontest.InQueue("a"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("b1", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("b2", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+				sessiontest.BuildJob("b3", customFieldsType,
+					sessiontest.InQueue("b"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(cpu("2"))),
+			},
+			expected: map[info.JobID]int32{"a1": 1, "a2": 1, "a3": 1, "a4": 0, "b1": 1, "b2": 0, "b3": 0},
+		},
+	}
+
+	policy, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	for _, test := range tests {
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nodeResources), test.jobs...)
+		for _, queue := range test.queues {
+			snapshot.Queues[queue.Name] = queue
+		}
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+		session.ExecutePolicies(ssn, []session.Policy{policy})
+
+		for id, expected := range test.expected {
+			if ssn.Jobs[id].NumReplicas != expected {
+				t.Errorf("%s: job %v expected %d replicas, got %d", test.name, id, expected, ssn.Jobs[id].NumReplicas)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package drf
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"k8s.io/klog"
+	"math"
+	"reflect"
+	"sort"
+)
+
+type JobCustomFields = session.RoleCustomFields
+
+// Policy is Dominant Resource Fairness across tenants, where the jobs of each namespace belong to one
+// tenant. The share of a tenant in a resource is the fraction of the cluster's resource allocated to
+// its jobs, and its dominant share is the largest of its shares in CPU, memory and GPU. Waiting jobs
+// are started one at a time from the tenant with the lowest dominant share, first come first served
+// within a tenant. Running jobs keep their resources.
+//
+// Waiting jobs of higher priority are started before the others regardless of the shares of their
+// tenants, preempting running jobs of lower priority if they do not fit.
+type Policy struct{}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	return &Policy{}, nil
+}
+
+func (drf *Policy) Name() string {
+	return "drf"
+}
+
+func (drf *Policy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*JobCustomFields)(nil))
+}
+
+func (drf *Policy) Initialize() {}
+
+// tenant is the jobs of a namespace
+type tenant struct {
+	namespace string
+	allocated *info.Resource
+	waiting   []*info.JobInfo
+}
+
+func (drf *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin DRF")
+	defer klog.V(3).Infof("End DRF")
+
+	total := info.EmptyResource()
+	for _, node := range ssn.Nodes {
+		total.Add(node.Allocatable)
+	}
+
+	// Running jobs keep their resources and count towards the shares of their tenants
+	pool := session.NewNodePool(ssn)
+	tenants := make(map[string]*tenant)
+	var running []*info.JobInfo
+	allocated := make(map[info.JobID]*info.Resource)
+	for _, job := range ssn.Jobs {
+		t, found := tenants[job.Namespace]
+		if !found {
+			t = &tenant{
+				namespace: job.Namespace,
+				allocated: info.EmptyResource(),
+			}
+			tenants[job.Namespace] = t
+		}
+
+		if job.NumMasters+job.NumReplicas == 0 {
+			t.waiting = append(t.waiting, job)
+			continue
+		}
+		if !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+			klog.Warningf("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+		}
+		allocated[job.UID] = pool.Allocated(job)
+		t.allocated.Add(allocated[job.UID])
+		running = append(running, job)
+	}
+	for _, t := range tenants {
+		sort.Slice(t.waiting, func(i, j int) bool {
+			return session.JobLess(t.waiting[i], t.waiting[j])
+		})
+	}
+
+	// Schedule
+	for {
+		var next *tenant
+		minShare := math.MaxFloat64
+		for _, t := range tenants {
+			if len(t.waiting) == 0 {
+				continue
+			}
+			share := session.DominantShare(t.allocated, total)
+			if next != nil && t.waiting[0].Priority != next.waiting[0].Priority {
+				if t.waiting[0].Priority > next.waiting[0].Priority {
+					next = t
+					minShare = share
+				}
+				continue
+			}
+			if next == nil || share < minShare || (share == minShare && t.namespace < next.namespace) {
+				next = t
+				minShare = share
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		job := next.waiting[0]
+		next.waiting = next.waiting[1:]
+		numMasters, numReplicas := session.RequestedRoles(job)
+		if !pool.AllocateJob(job, numMasters, numReplicas) {
+			victims := pool.Preempt(job, numMasters, numReplicas, running)
+			if victims == nil {
+				ssn.Explainf(job, session.ReasonInsufficientResources, "tenant %s at dominant share %.2f, %s",
+					next.namespace, minShare, pool.Shortage(job, numMasters, numReplicas))
+				continue
+			}
+			for _, victim := range victims {
+				klog.V(4).Infof("Preempting job <%s/%s> for job <%s/%s> of higher priority",
+					victim.Namespace, victim.Name, job.Namespace, job.Name)
+				tenants[victim.Namespace].allocated.Sub(allocated[victim.UID])
+				victim.NumMasters = 0
+				victim.NumReplicas = 0
+				ssn.Explainf(victim, session.ReasonPreempted, "preempted by job %s/%s of priority %d",
+					job.Namespace, job.Name, job.Priority)
+			}
+			running = session.StillRunning(running)
+		}
+		job.NumMasters = numMasters
+		job.NumReplicas = numReplicas
+		allocated[job.UID] = pool.Allocated(job)
+		next.allocated.Add(allocated[job.UID])
+		running = append(running, job)
+		klog.V(4).Infof("Started job <%s/%s> of tenant %s with dominant share %v",
+			job.Namespace, job.Name, next.namespace, minShare)
+		ssn.Explainf(job, session.ReasonScheduled, "tenant %s at dominant share %.2f", next.namespace, minShare)
+	}
+}
+
+func (drf *Policy) UnInitialize() {}
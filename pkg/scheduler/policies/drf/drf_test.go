@@ -0,0 +1,151 @@
+package drf
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+// nodeResources are the resources of each node
+var nodeResources = v1.ResourceList{
+	v1.ResourceCPU:    resource.MustParse("4"),
+	v1.ResourceMemory: resource.MustParse("16Gi"),
+}
+
+func TestPolicy_Execute(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cpuHeavy := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("2"),
+		v1.ResourceMemory: resource.MustParse("2Gi"),
+	}
+	memoryHeavy := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("1"),
+		v1.ResourceMemory: resource.MustParse("8Gi"),
+	}
+
+	tests := []struct {
+		name     string
+		numNodes int
+		jobs     []*info.JobInfo
+		expected map[info.JobID]int32
+	}{
+		{
+			name:     "flooding tenant does not starve the others",
+			numNodes: 2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-6*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j4", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+			},
+			expected: map[info.JobID]int32{"a/j1": 1, "a/j2": 1, "a/j3": 0, "a/j4": 0, "b/j1": 1, "b/j2": 1},
+		},
+		{
+			name:     "running jobs count towards the share",
+			numNodes: 2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+			},
+			expected: map[info.JobID]int32{"a/j1": 1, "a/j2": 1, "a/j3": 0, "b/j1": 1, "b/j2": 1},
+		},
+		{
+			name:     "share of the dominant resource",
+			numNodes: 3,
+			jobs: []*info.JobInfo{
+				// 1/3 of the CPU
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.Running(2), sessiontest.WithReplicaResources(cpuHeavy)),
+				// 1/4 of the CPU but 1/2 of the memory
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.Running(3), sessiontest.WithReplicaResources(memoryHeavy)),
+				// Only one of these fits
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithReplicaResources(memoryHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(memoryHeavy)),
+			},
+			expected: map[info.JobID]int32{"a/j1": 2, "a/j2": 1, "b/j1": 3, "b/j2": 0},
+		},
+		{
+			name:     "higher priority preempts",
+			numNodes: 2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-6*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j4", customFieldsType,
+					sessiontest.InNamespace("a"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.Running(1), sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("b"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithReplicaResources(cpuHeavy), sessiontest.WithPriority(1, true)),
+			},
+			expected: map[info.JobID]int32{"a/j1": 1, "a/j2": 1, "a/j3": 1, "a/j4": 0, "b/j1": 0, "b/j2": 1},
+		},
+	}
+
+	policy, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	for _, test := range tests {
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nodeResources), test.jobs...)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+		session.ExecutePolicies(ssn, []session.Policy{policy})
+
+		for id, expected := range test.expected {
+			if ssn.Jobs[id].NumReplicas != expected {
+				t.Errorf("%s: job %v expected %d replicas, got %d", test.name, id, expected, ssn.Jobs[id].NumReplicas)
+			}
+		}
+	}
+}
@@ -1,16 +1,36 @@
 package equi
 
 import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
 	"reflect"
+	"sort"
 )
 
 type JobCustomFields struct{}
 
-type Policy struct{}
+const (
+	// rebalanceThresholdKey is the max difference between the # replicas of any two jobs that is
+	// tolerated before the replicas are handed out again
+	rebalanceThresholdKey = "rebalanceThreshold"
+)
+
+type Policy struct {
+	rebalanceThreshold int
+}
 
-func New() *Policy {
-	return &Policy{}
+func New(arguments session.Arguments) (session.Policy, error) {
+	equi := &Policy{
+		rebalanceThreshold: 1,
+	}
+	if err := arguments.GetInt(&equi.rebalanceThreshold, rebalanceThresholdKey); err != nil {
+		return nil, err
+	}
+	if equi.rebalanceThreshold < 0 {
+		return nil, fmt.Errorf("argument %s must not be negative", rebalanceThresholdKey)
+	}
+	return equi, nil
 }
 
 func (equi *Policy) Name() string {
@@ -24,50 +44,164 @@ func (equi *Policy) JobCustomFieldsType() reflect.Type {
 func (equi *Policy) Initialize() {}
 
 func (equi *Policy) Execute(ssn *session.Session) {
-	numNodes := len(ssn.Nodes)
-
 	if len(ssn.Jobs) == 0 {
 		return
 	}
-	// 1st judge
-	judge := make(map[int32]bool)
-
-	for _, job := range ssn.Jobs {
-		judge[job.NumReplicas] = true
+	if equi.balanced(ssn) {
+		explain(ssn)
+		return
 	}
-	if len(judge) <= 2 {
-		// 2nd judge
-		sumReplicas := 0
-		for _, job := range ssn.Jobs {
-			sumReplicas += int(job.NumReplicas)
+
+	// Hand out replicas round-robin until no job can fit one more replica, to the jobs of higher priority
+	// first. Jobs start with their min # masters and replicas at once. Running jobs that cannot be
+	// preempted keep their replicas.
+	pool := session.NewNodePool(ssn)
+	kept := pool.KeepNonPreemptible(ssn.Jobs)
+	for id, job := range ssn.Jobs {
+		if !kept[id] {
+			job.NumMasters = 0
+			job.NumReplicas = 0
 		}
-		if numNodes == sumReplicas {
-			// 3rd judge
-			if len(judge) == 1 {
-				return
-			}
-			var judgeArr []int
-			for key := range judge {
-				judgeArr = append(judgeArr, int(key))
+	}
+	for _, jobs := range byPriority(ssn.Jobs) {
+		for {
+			allocated := false
+			for _, job := range jobs {
+				if grow(pool, job) {
+					allocated = true
+				}
 			}
-			if judgeArr[0]-judgeArr[1] == 1 || judgeArr[0]-judgeArr[1] == -1 {
-				return
+			if !allocated {
+				break
 			}
 		}
 	}
+	explain(ssn)
+}
 
+// explain attaches the reasons for the allocations of the jobs, once no job can grow
+func explain(ssn *session.Session) {
+	pool := session.NewNodePool(ssn)
+	for _, job := range ssn.Jobs {
+		pool.AllocateJob(job, job.NumMasters, job.NumReplicas)
+	}
 	for _, job := range ssn.Jobs {
-		job.NumReplicas = 0
-	}
-	for {
-		for _, job := range ssn.Jobs {
-			job.NumReplicas++
-			numNodes--
-			if numNodes == 0 {
-				return
+		switch {
+		case job.NumReplicas == 0:
+			ssn.Explainf(job, session.ReasonInsufficientResources, "%s for an equal share",
+				pool.Shortage(job, job.MinMasters, job.MinReplicas))
+		case job.NumReplicas == job.MaxReplicas:
+			ssn.Explainf(job, session.ReasonScheduled, "equal share capped at max of %d replicas", job.MaxReplicas)
+		default:
+			ssn.Explainf(job, session.ReasonScheduled, "equal share of %d replicas", job.NumReplicas)
+		}
+	}
+}
+
+// grow gives the job one more replica, or its min # masters and replicas if it does not run yet, and
+// returns whether they fit
+func grow(pool *session.NodePool, job *info.JobInfo) bool {
+	if job.NumReplicas == 0 {
+		if !pool.AllocateJob(job, job.MinMasters, job.MinReplicas) {
+			return false
+		}
+		job.NumMasters = job.MinMasters
+		job.NumReplicas = job.MinReplicas
+		return true
+	}
+	if job.NumReplicas >= job.MaxReplicas || !pool.AllocateJob(job, 0, 1) {
+		return false
+	}
+	job.NumReplicas++
+	return true
+}
+
+// canGrow returns whether grow would succeed
+func canGrow(pool *session.NodePool, job *info.JobInfo) bool {
+	if job.NumReplicas == 0 {
+		return pool.MaxReplicas(job, job.MinMasters) >= int(job.MinReplicas)
+	}
+	return job.NumReplicas < job.MaxReplicas && pool.MaxReplicas(job, 0) > 0
+}
+
+// byPriority groups the jobs by priority, highest first, each group first come first served
+func byPriority(jobs map[info.JobID]*info.JobInfo) [][]*info.JobInfo {
+	groups := make(map[int32][]*info.JobInfo)
+	var priorities []int32
+	for _, job := range jobs {
+		if _, found := groups[job.Priority]; !found {
+			priorities = append(priorities, job.Priority)
+		}
+		groups[job.Priority] = append(groups[job.Priority], job)
+	}
+	sort.Slice(priorities, func(i, j int) bool {
+		return priorities[i] > priorities[j]
+	})
+	sorted := make([][]*info.JobInfo, len(priorities))
+	for i, priority := range priorities {
+		group := groups[priority]
+		sort.Slice(group, func(i, j int) bool {
+			return session.ArrivedBefore(group[i], group[j])
+		})
+		sorted[i] = group
+	}
+	return sorted
+}
+
+// balanced returns whether the current allocation fits in the cluster within the bounds of the jobs, no
+// job can fit one more replica, no job could take the replicas of a job of lower priority, and the #
+// replicas of jobs of the same priority pinned to the same node type differ by at most the threshold,
+// not counting jobs at their max # replicas.
+func (equi *Policy) balanced(ssn *session.Session) bool {
+	// Allocate pinned jobs first, so that jobs without a node type take what is left
+	jobs := make([]*info.JobInfo, 0, len(ssn.Jobs))
+	for _, job := range ssn.Jobs {
+		jobs = append(jobs, job)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].ReplicaNodeType != "" && jobs[j].ReplicaNodeType == ""
+	})
+
+	// Jobs of the same priority pinned to the same node type are balanced with each other
+	type group struct {
+		nodeType string
+		priority int32
+	}
+	pool := session.NewNodePool(ssn)
+	minReplicas := make(map[group]int32)
+	maxReplicas := make(map[group]int32)
+	for _, job := range jobs {
+		if !job.InBounds(job.NumMasters, job.NumReplicas) || !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+			return false
+		}
+		if job.NumReplicas == job.MaxReplicas {
+			continue
+		}
+		g := group{nodeType: job.ReplicaNodeType, priority: job.Priority}
+		if n, found := minReplicas[g]; !found || job.NumReplicas < n {
+			minReplicas[g] = job.NumReplicas
+		}
+		if n, found := maxReplicas[g]; !found || job.NumReplicas > n {
+			maxReplicas[g] = job.NumReplicas
+		}
+	}
+	for _, job := range jobs {
+		if canGrow(pool, job) {
+			return false
+		}
+		for _, other := range jobs {
+			if other.NumReplicas > 0 && session.CanPreempt(job, other) &&
+				(job.ReplicaNodeType == "" || job.ReplicaNodeType == other.ReplicaNodeType) {
+				return false
 			}
 		}
 	}
+	for g := range minReplicas {
+		if int(maxReplicas[g]-minReplicas[g]) > equi.rebalanceThreshold {
+			return false
+		}
+	}
+	return true
 }
 
 func (equi *Policy) UnInitialize() {}
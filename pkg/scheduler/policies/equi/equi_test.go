@@ -0,0 +1,104 @@
+package equi
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Execute(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments session.Arguments
+		jobs      []*info.JobInfo
+		expected  map[info.JobID]int32
+	}{
+		{
+			name: "jobs pinned to node types",
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", nil, sessiontest.WithReplicaNodeType("cpu")),
+				sessiontest.BuildJob("j2", nil, sessiontest.WithReplicaNodeType("cpu")),
+				sessiontest.BuildJob("j3", nil, sessiontest.WithReplicaNodeType("gpu")),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 2, "j3": 1},
+		},
+		{
+			name: "balanced allocation is kept",
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", nil, sessiontest.WithReplicaNodeType("cpu"), sessiontest.Running(3)),
+				sessiontest.BuildJob("j2", nil, sessiontest.WithReplicaNodeType("cpu"), sessiontest.Running(1)),
+				sessiontest.BuildJob("j3", nil, sessiontest.WithReplicaNodeType("gpu"), sessiontest.Running(1)),
+			},
+			arguments: session.Arguments{"rebalanceThreshold": "2"},
+			expected:  map[info.JobID]int32{"j1": 3, "j2": 1, "j3": 1},
+		},
+		{
+			name: "unbalanced allocation is redone",
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", nil, sessiontest.WithReplicaNodeType("cpu"), sessiontest.Running(3)),
+				sessiontest.BuildJob("j2", nil, sessiontest.WithReplicaNodeType("cpu"), sessiontest.Running(1)),
+				sessiontest.BuildJob("j3", nil, sessiontest.WithReplicaNodeType("gpu"), sessiontest.Running(1)),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 2, "j3": 1},
+		},
+		{
+			name: "jobs of higher priority take the replicas that can be preempted",
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", nil, sessiontest.WithReplicaNodeType("cpu"), sessiontest.Running(2)),
+				sessiontest.BuildJob("j2", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.Running(2),
+					sessiontest.WithPriority(0, false)),
+				sessiontest.BuildJob("j3", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithPriority(1, true)),
+			},
+			expected: map[info.JobID]int32{"j1": 0, "j2": 2, "j3": 2},
+		},
+		{
+			name: "replicas within the bounds of the jobs",
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithReplicaBounds(1, 1)),
+				sessiontest.BuildJob("j2", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithReplicaBounds(3, 4)),
+				sessiontest.BuildJob("j3", nil,
+					sessiontest.WithReplicaNodeType("gpu"), sessiontest.WithReplicaBounds(2, 2)),
+			},
+			expected: map[info.JobID]int32{"j1": 1, "j2": 3, "j3": 0},
+		},
+		{
+			name: "first come first served within a priority",
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithReplicaBounds(2, 4),
+					sessiontest.CreatedAt(time.Unix(1003, 0))),
+				sessiontest.BuildJob("j2", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithReplicaBounds(2, 4),
+					sessiontest.CreatedAt(time.Unix(1002, 0))),
+				sessiontest.BuildJob("j3", nil,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithReplicaBounds(2, 4),
+					sessiontest.CreatedAt(time.Unix(1001, 0))),
+			},
+			expected: map[info.JobID]int32{"j1": 0, "j2": 2, "j3": 2},
+		},
+	}
+
+	for _, test := range tests {
+		policy, err := New(test.arguments)
+		if err != nil {
+			t.Errorf("%s: failed to build policy: %v", test.name, err)
+			continue
+		}
+
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodesByType(map[string]int{"cpu": 4, "gpu": 1}), test.jobs...)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, time.Now())
+		policy.Execute(ssn)
+
+		for id, expected := range test.expected {
+			if ssn.Jobs[id].NumReplicas != expected {
+				t.Errorf("%s: job %v expected %d replicas, got %d", test.name, id, expected, ssn.Jobs[id].NumReplicas)
+			}
+		}
+	}
+}
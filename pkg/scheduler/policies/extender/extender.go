@@ -0,0 +1,354 @@
+package extender
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/metrics"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"io"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// urlKey is the HTTP endpoint of the extender, required
+	urlKey = "url"
+	// timeoutKey is the # seconds the extender has to respond
+	timeoutKey = "timeout"
+	// fallbackKey is the policy executed instead when the extender fails or responds with invalid
+	// allocations
+	fallbackKey = "fallback"
+	// fallbackArgumentPrefix prefixes the arguments given to the fallback policy, e.g. fallback.quantum
+	fallbackArgumentPrefix = "fallback."
+
+	// maxResponseSize is the # bytes of a response read at most
+	maxResponseSize = 64 << 20
+)
+
+// Request is what the extender is sent each session
+type Request struct {
+	Session string      `json:"session"`
+	Time    metav1.Time `json:"time"`
+
+	Jobs      []Job               `json:"jobs"`
+	Nodes     []Node              `json:"nodes"`
+	NodeTypes map[string]NodeType `json:"nodeTypes"`
+}
+
+// Job is a job of the session, with its current allocation
+type Job struct {
+	ID        info.JobID            `json:"id"`
+	Name      string                `json:"name"`
+	Namespace string                `json:"namespace"`
+	Type      pintav1.PintaJobType  `json:"type"`
+	State     pintav1.PintaJobState `json:"state,omitempty"`
+
+	NumMasters  int32 `json:"numMasters"`
+	NumReplicas int32 `json:"numReplicas"`
+
+	MinMasters  int32 `json:"minMasters"`
+	MaxMasters  int32 `json:"maxMasters"`
+	MinReplicas int32 `json:"minReplicas"`
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	MasterNodeType   string          `json:"masterNodeType,omitempty"`
+	ReplicaNodeType  string          `json:"replicaNodeType,omitempty"`
+	MasterResources  v1.ResourceList `json:"masterResources,omitempty"`
+	ReplicaResources v1.ResourceList `json:"replicaResources,omitempty"`
+
+	Queue       string `json:"queue,omitempty"`
+	Priority    int32  `json:"priority"`
+	Preemptible bool   `json:"preemptible"`
+
+	CreationTimestamp metav1.Time       `json:"creationTimestamp"`
+	Progress          *info.JobProgress `json:"progress,omitempty"`
+	// CustomFields is the custom fields annotation of the job, unparsed
+	CustomFields string `json:"customFields,omitempty"`
+}
+
+// Node is a node of the session
+type Node struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Allocatable v1.ResourceList `json:"allocatable"`
+	Capacity    v1.ResourceList `json:"capacity"`
+}
+
+// NodeType is the resources all nodes of a type have in common
+type NodeType struct {
+	Resources v1.ResourceList `json:"resources"`
+	Nodes     []string        `json:"nodes"`
+}
+
+// Response is the allocations the extender makes. Jobs it leaves out keep their current allocations.
+type Response struct {
+	Allocations []session.Allocation `json:"allocations"`
+}
+
+// Policy delegates the allocations to an external service over HTTP, so that policies can be developed
+// in any language without rebuilding the scheduler. Each session, the jobs, nodes and node types are
+// POSTed to the extender as JSON, and the allocations it responds with are applied as a whole once they
+// are validated: every job must be in the session, within its bounds, running jobs that cannot be
+// preempted must keep their masters and replicas, and all of them must fit in the cluster together.
+//
+// When the extender cannot be reached, takes longer than the timeout, or responds with invalid
+// allocations, the fallback policy is executed instead. Job custom fields are parsed for the fallback,
+// which only keeps its job states in the sessions it is executed.
+type Policy struct {
+	url      string
+	client   *http.Client
+	fallback session.Policy
+}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	extender := &Policy{}
+
+	extender.url = strings.TrimSpace(arguments[urlKey])
+	if extender.url == "" {
+		return nil, fmt.Errorf("argument %s is required", urlKey)
+	}
+	u, err := url.Parse(extender.url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("argument %s: %q is not an HTTP URL", urlKey, extender.url)
+	}
+
+	timeout := 5.0
+	if err := arguments.GetFloat64(&timeout, timeoutKey); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("argument %s must be positive", timeoutKey)
+	}
+	extender.client = &http.Client{Timeout: time.Duration(timeout * float64(time.Second))}
+
+	fallbackName := "fcfs"
+	if name, found := arguments[fallbackKey]; found {
+		fallbackName = strings.TrimSpace(name)
+	}
+	if fallbackName == extender.Name() {
+		return nil, fmt.Errorf("argument %s: the extender cannot fall back to itself", fallbackKey)
+	}
+	builder, found := session.GetPolicyBuilder(fallbackName)
+	if !found {
+		return nil, fmt.Errorf("argument %s: failed to found Policy %s", fallbackKey, fallbackName)
+	}
+	fallbackArguments := session.Arguments{}
+	for key, value := range arguments {
+		if strings.HasPrefix(key, fallbackArgumentPrefix) {
+			fallbackArguments[strings.TrimPrefix(key, fallbackArgumentPrefix)] = value
+		}
+	}
+	if extender.fallback, err = builder(fallbackArguments); err != nil {
+		return nil, fmt.Errorf("invalid arguments for fallback Policy %s: %v", fallbackName, err)
+	}
+	return extender, nil
+}
+
+func (extender *Policy) Name() string {
+	return "extender"
+}
+
+func (extender *Policy) JobCustomFieldsType() reflect.Type {
+	return extender.fallback.JobCustomFieldsType()
+}
+
+func (extender *Policy) Initialize() {
+	extender.fallback.Initialize()
+}
+
+func (extender *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin extender")
+	defer klog.V(3).Infof("End extender")
+
+	response, err := extender.send(buildRequest(ssn))
+	if err != nil {
+		extender.fallBack(ssn, "unavailable", err)
+		return
+	}
+	allocations, err := validate(ssn, response)
+	if err != nil {
+		extender.fallBack(ssn, "invalid", err)
+		return
+	}
+
+	for id, allocation := range allocations {
+		job := ssn.Jobs[id]
+		job.NumMasters = allocation.NumMasters
+		job.NumReplicas = allocation.NumReplicas
+		if allocation.Reason == "" && allocation.Message == "" {
+			continue
+		}
+		reason := allocation.Reason
+		if reason == "" && job.NumMasters+job.NumReplicas > 0 {
+			reason = session.ReasonScheduled
+		} else if reason == "" {
+			reason = session.ReasonNotScheduled
+		}
+		ssn.Explainf(job, reason, "%s", allocation.Message)
+	}
+}
+
+func (extender *Policy) UnInitialize() {
+	extender.fallback.UnInitialize()
+}
+
+// fallBack executes the fallback policy on the session in place of the extender
+func (extender *Policy) fallBack(ssn *session.Session, reason string, err error) {
+	klog.Errorf("Extender %s failed, falling back to policy %s: %v", extender.url, extender.fallback.Name(), err)
+	metrics.RecordExtenderFallback(reason)
+	session.ExecutePolicies(ssn, []session.Policy{extender.fallback})
+}
+
+// send POSTs the request to the extender and decodes its response
+func (extender *Policy) send(request *Request) (*Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode request: %v", err)
+	}
+	resp, err := extender.client.Post(extender.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extender responded with status %s", resp.Status)
+	}
+	response := &Response{}
+	// Responses cut short by the limit fail to decode
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(response); err != nil {
+		return nil, fmt.Errorf("cannot decode response: %v", err)
+	}
+	return response, nil
+}
+
+// buildRequest returns the request describing the session, with jobs and nodes in order of ID and name
+func buildRequest(ssn *session.Session) *Request {
+	request := &Request{
+		Session:   string(ssn.UID),
+		Time:      metav1.NewTime(ssn.Now()),
+		Jobs:      make([]Job, 0, len(ssn.Jobs)),
+		Nodes:     make([]Node, 0, len(ssn.Nodes)),
+		NodeTypes: make(map[string]NodeType, len(ssn.NodeTypes)),
+	}
+	for id, job := range ssn.Jobs {
+		var state pintav1.PintaJobState
+		if len(job.Job.Status) > 0 {
+			state = job.Job.Status[0].State
+		}
+		request.Jobs = append(request.Jobs, Job{
+			ID:        id,
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			Type:      job.Type,
+			State:     state,
+
+			NumMasters:  job.NumMasters,
+			NumReplicas: job.NumReplicas,
+
+			MinMasters:  job.MinMasters,
+			MaxMasters:  job.MaxMasters,
+			MinReplicas: job.MinReplicas,
+			MaxReplicas: job.MaxReplicas,
+
+			MasterNodeType:   job.MasterNodeType,
+			ReplicaNodeType:  job.ReplicaNodeType,
+			MasterResources:  job.MasterResources,
+			ReplicaResources: job.ReplicaResources,
+
+			Queue:       job.Queue,
+			Priority:    job.Priority,
+			Preemptible: job.Preemptible,
+
+			CreationTimestamp: job.CreationTimestamp,
+			Progress:          job.Progress,
+			CustomFields:      job.Job.GetAnnotations()["pinta.qed.usc.edu/custom-fields"],
+		})
+	}
+	for _, node := range ssn.Nodes {
+		request.Nodes = append(request.Nodes, Node{
+			Name:        node.Name,
+			Type:        node.Type,
+			Allocatable: node.Allocatable.ToResourceList(),
+			Capacity:    node.Capacity.ToResourceList(),
+		})
+	}
+	for name, nodeType := range ssn.NodeTypes {
+		nodes := make([]string, 0, len(nodeType.Nodes))
+		for _, node := range nodeType.Nodes {
+			nodes = append(nodes, node.Name)
+		}
+		sort.Strings(nodes)
+		request.NodeTypes[name] = NodeType{
+			Resources: nodeType.Resource.ToResourceList(),
+			Nodes:     nodes,
+		}
+	}
+	sort.Slice(request.Jobs, func(i, j int) bool {
+		return request.Jobs[i].ID < request.Jobs[j].ID
+	})
+	sort.Slice(request.Nodes, func(i, j int) bool {
+		return request.Nodes[i].Name < request.Nodes[j].Name
+	})
+	return request
+}
+
+// validate checks the allocations of the response and returns them by job. Every job must be in the
+// session at most once and within its bounds, running jobs that cannot be preempted must not lose
+// masters or replicas, and the jobs must fit in the cluster together with the jobs the response leaves
+// out.
+func validate(ssn *session.Session, response *Response) (map[info.JobID]session.Allocation, error) {
+	allocations := make(map[info.JobID]session.Allocation, len(response.Allocations))
+	for _, allocation := range response.Allocations {
+		job, found := ssn.Jobs[allocation.Job]
+		if !found {
+			return nil, fmt.Errorf("unknown job %s", allocation.Job)
+		}
+		if _, found := allocations[allocation.Job]; found {
+			return nil, fmt.Errorf("job %s is allocated more than once", allocation.Job)
+		}
+		if allocation.NumMasters < 0 || allocation.NumReplicas < 0 {
+			return nil, fmt.Errorf("job %s is allocated a negative # masters or replicas", allocation.Job)
+		}
+		if !job.InBounds(allocation.NumMasters, allocation.NumReplicas) {
+			return nil, fmt.Errorf("job %s: %d masters and %d replicas are out of bounds [%d, %d] and [%d, %d]",
+				allocation.Job, allocation.NumMasters, allocation.NumReplicas,
+				job.MinMasters, job.MaxMasters, job.MinReplicas, job.MaxReplicas)
+		}
+		if !job.Preemptible && (allocation.NumMasters < job.NumMasters || allocation.NumReplicas < job.NumReplicas) {
+			return nil, fmt.Errorf("job %s cannot be preempted, but %d masters and %d replicas are lowered to %d and %d",
+				allocation.Job, job.NumMasters, job.NumReplicas, allocation.NumMasters, allocation.NumReplicas)
+		}
+		allocations[allocation.Job] = allocation
+	}
+
+	jobs := make([]*info.JobInfo, 0, len(ssn.Jobs))
+	for _, job := range ssn.Jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].UID < jobs[j].UID
+	})
+	pool := session.NewNodePool(ssn)
+	for _, job := range jobs {
+		numMasters, numReplicas := job.NumMasters, job.NumReplicas
+		if allocation, found := allocations[job.UID]; found {
+			numMasters, numReplicas = allocation.NumMasters, allocation.NumReplicas
+		}
+		if numMasters+numReplicas > 0 && !pool.AllocateJob(job, numMasters, numReplicas) {
+			return nil, fmt.Errorf("job %s: %d masters and %d replicas do not fit in the cluster",
+				job.UID, numMasters, numReplicas)
+		}
+	}
+	return allocations, nil
+}
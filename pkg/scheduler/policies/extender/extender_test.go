@@ -0,0 +1,199 @@
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/fcfs"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func init() {
+	session.RegisterPolicyBuilder("fcfs", fcfs.New)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments session.Arguments
+		valid     bool
+	}{
+		{name: "defaults", arguments: session.Arguments{"url": "http://localhost:8080/schedule"}, valid: true},
+		{name: "no url", arguments: session.Arguments{}},
+		{name: "not http", arguments: session.Arguments{"url": "unix:///tmp/extender.sock"}},
+		{name: "non-positive timeout", arguments: session.Arguments{"url": "http://localhost", "timeout": "0"}},
+		{name: "unknown fallback", arguments: session.Arguments{"url": "http://localhost", "fallback": "missing"}},
+		{name: "fall back to itself", arguments: session.Arguments{"url": "http://localhost", "fallback": "extender"}},
+		{
+			name:      "fallback arguments",
+			arguments: session.Arguments{"url": "http://localhost", "fallback.backfill": "true"},
+			valid:     true,
+		},
+		{
+			name:      "invalid fallback arguments",
+			arguments: session.Arguments{"url": "http://localhost", "fallback.backfill": "maybe"},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.arguments)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestPolicy_Execute(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected map[info.JobID]int32
+		reason   string
+	}{
+		{
+			name: "applied",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				request := &Request{}
+				if err := json.NewDecoder(r.Body).Decode(request); err != nil || len(request.Jobs) != 2 || len(request.Nodes) != 3 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				_, _ = fmt.Fprint(w, `{"allocations": [{"job": "j2", "numReplicas": 2, "reason": "Shortest", "message": "smallest job first"}]}`)
+			},
+			expected: map[info.JobID]int32{"j1": 0, "j2": 2},
+			reason:   "Shortest",
+		},
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+			reason:   session.ReasonInsufficientResources,
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(300 * time.Millisecond)
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+			reason:   session.ReasonInsufficientResources,
+		},
+		{
+			name: "malformed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `{"allocations": [`)
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+			reason:   session.ReasonInsufficientResources,
+		},
+		{
+			name: "unknown job",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `{"allocations": [{"job": "j3", "numReplicas": 1}]}`)
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+			reason:   session.ReasonInsufficientResources,
+		},
+		{
+			name: "out of bounds",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `{"allocations": [{"job": "j2", "numReplicas": 3}]}`)
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+			reason:   session.ReasonInsufficientResources,
+		},
+		{
+			name: "does not fit",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `{"allocations": [{"job": "j1", "numReplicas": 2}, {"job": "j2", "numReplicas": 2}]}`)
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+			reason:   session.ReasonInsufficientResources,
+		},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(test.handler)
+		policy, err := New(session.Arguments{"url": server.URL, "timeout": "0.1"})
+		if err != nil {
+			t.Fatalf("%s: failed to build policy: %v", test.name, err)
+		}
+
+		j1 := sessiontest.BuildJob("j1", nil,
+			sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithReplicaBounds(1, 2),
+			sessiontest.WithCustomFields("numReplicas: 2"))
+		j2 := sessiontest.BuildJob("j2", nil,
+			sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithReplicaBounds(1, 2),
+			sessiontest.WithCustomFields("numReplicas: 2"))
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", 3, nil), j1, j2)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+		policies := []session.Policy{policy}
+		for _, job := range ssn.Jobs {
+			if err := job.ParseCustomFields(policy.JobCustomFieldsType()); err != nil {
+				t.Fatalf("%s: failed to parse custom fields: %v", test.name, err)
+			}
+		}
+		policy.Initialize()
+		session.ExecutePolicies(ssn, policies)
+		policy.UnInitialize()
+		server.Close()
+
+		for id, numReplicas := range test.expected {
+			if ssn.Jobs[id].NumReplicas != numReplicas {
+				t.Errorf("%s: expected job %s to have %d replicas, got %d", test.name, id, numReplicas, ssn.Jobs[id].NumReplicas)
+			}
+		}
+		if explanation := ssn.Explanation(j2); explanation.Reason != test.reason {
+			t.Errorf("%s: expected job j2 to be explained by %s, got %+v", test.name, test.reason, explanation)
+		}
+	}
+}
+
+func TestValidate_NonPreemptible(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		name        string
+		allocation  session.Allocation
+		expectError bool
+	}{
+		{
+			name:       "kept",
+			allocation: session.Allocation{Job: "j1", NumReplicas: 2},
+		},
+		{
+			name:       "grown",
+			allocation: session.Allocation{Job: "j1", NumReplicas: 3},
+		},
+		{
+			name:        "shrunk",
+			allocation:  session.Allocation{Job: "j1", NumReplicas: 1},
+			expectError: true,
+		},
+		{
+			name:        "stopped",
+			allocation:  session.Allocation{Job: "j1"},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		j1 := sessiontest.BuildJob("j1", nil,
+			sessiontest.CreatedAt(now), sessiontest.WithReplicaBounds(1, 3),
+			sessiontest.WithPriority(0, false), sessiontest.Running(2))
+		ssn := session.OpenSessionWithSnapshot(sessiontest.BuildSnapshot(sessiontest.BuildNodes("", 3, nil), j1), nil, now)
+		_, err := validate(ssn, &Response{Allocations: []session.Allocation{test.allocation}})
+		if test.expectError && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		} else if !test.expectError && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+}
@@ -1,16 +1,30 @@
 package policies
 
 import (
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/capacity"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/drf"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/equi"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/extender"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/fcfs"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/hell"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/las"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/nop"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/optimus"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/scripted"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/timeslice"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
 )
 
 func init() {
-	session.RegisterPolicy(nop.New())
-	session.RegisterPolicy(fcfs.New())
-	session.RegisterPolicy(equi.New())
-	session.RegisterPolicy(hell.New())
+	session.RegisterPolicyBuilder("nop", nop.New)
+	session.RegisterPolicyBuilder("fcfs", fcfs.New)
+	session.RegisterPolicyBuilder("equi", equi.New)
+	session.RegisterPolicyBuilder("hell", hell.New)
+	session.RegisterPolicyBuilder("las", las.New)
+	session.RegisterPolicyBuilder("optimus", optimus.New)
+	session.RegisterPolicyBuilder("timeslice", timeslice.New)
+	session.RegisterPolicyBuilder("drf", drf.New)
+	session.RegisterPolicyBuilder("capacity", capacity.New)
+	session.RegisterPolicyBuilder("extender", extender.New)
+	session.RegisterPolicyBuilder("scripted", scripted.New)
 }
@@ -1,30 +1,266 @@
 package fcfs
 
 import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"k8s.io/klog"
+	"math"
 	"reflect"
+	"sort"
+	"time"
 )
 
-type JobCustomFields struct{}
+const (
+	// orderByKey is the list of keys jobs are ordered by
+	orderByKey = "orderBy"
+	// headOfLineBlockingKey makes jobs wait behind the first job that does not fit
+	headOfLineBlockingKey = "headOfLineBlocking"
+	// backfillKey lets jobs behind a blocked job start with EASY backfilling
+	backfillKey = "backfill"
+)
+
+type JobCustomFields struct {
+	session.RoleCustomFields `yaml:",inline"`
+	// EstimatedRuntime is the estimated runtime of the job in seconds, used for backfilling
+	EstimatedRuntime float64 `yaml:"estimatedRuntime"`
+}
 
-type Policy struct{}
+type Policy struct {
+	less               []lessFunc
+	headOfLineBlocking bool
+	backfill           bool
+}
+
+type lessFunc func(l, r *info.JobInfo) (less bool, equal bool)
+
+var orderKeys = map[string]lessFunc{
+	// Higher priority first
+	"priority": func(l, r *info.JobInfo) (bool, bool) {
+		return l.Priority > r.Priority, l.Priority == r.Priority
+	},
+	"creationTimestamp": func(l, r *info.JobInfo) (bool, bool) {
+		return l.CreationTimestamp.Before(&r.CreationTimestamp), l.CreationTimestamp.Equal(&r.CreationTimestamp)
+	},
+	"uid": func(l, r *info.JobInfo) (bool, bool) {
+		return l.UID < r.UID, l.UID == r.UID
+	},
+	"name": func(l, r *info.JobInfo) (bool, bool) {
+		return l.Name < r.Name, l.Name == r.Name
+	},
+	"namespace": func(l, r *info.JobInfo) (bool, bool) {
+		return l.Namespace < r.Namespace, l.Namespace == r.Namespace
+	},
+}
 
-func New() *Policy {
-	return &Policy{}
+func New(arguments session.Arguments) (session.Policy, error) {
+	orderBy := []string{"priority", "creationTimestamp", "uid"}
+	fcfs := &Policy{
+		headOfLineBlocking: true,
+	}
+	if err := arguments.GetStringList(&orderBy, orderByKey); err != nil {
+		return nil, err
+	}
+	if err := arguments.GetBool(&fcfs.headOfLineBlocking, headOfLineBlockingKey); err != nil {
+		return nil, err
+	}
+	if err := arguments.GetBool(&fcfs.backfill, backfillKey); err != nil {
+		return nil, err
+	}
+	if fcfs.backfill && !fcfs.headOfLineBlocking {
+		return nil, fmt.Errorf("argument %s requires %s", backfillKey, headOfLineBlockingKey)
+	}
+	for _, key := range orderBy {
+		less, found := orderKeys[key]
+		if !found {
+			return nil, fmt.Errorf("argument %s: unknown key %s", orderByKey, key)
+		}
+		fcfs.less = append(fcfs.less, less)
+	}
+	return fcfs, nil
 }
 
 func (fcfs *Policy) Name() string {
 	return "fcfs"
 }
 
-func (hell *Policy) JobCustomFieldsType() reflect.Type {
+func (fcfs *Policy) JobCustomFieldsType() reflect.Type {
 	return reflect.TypeOf((*JobCustomFields)(nil))
 }
 
 func (fcfs *Policy) Initialize() {}
 
+// allocation is a job holding its resources until its estimated end time
+type allocation struct {
+	job *info.JobInfo
+	end time.Time
+}
+
 func (fcfs *Policy) Execute(ssn *session.Session) {
-	// Do nothing
+	klog.V(3).Infof("Begin FCFS")
+	defer klog.V(3).Infof("End FCFS")
+
+	now := ssn.Now()
+
+	// Running jobs keep their resources
+	pool := session.NewNodePool(ssn)
+	var allocations []allocation
+	var queue []*info.JobInfo
+	for _, job := range ssn.Jobs {
+		if job.NumMasters+job.NumReplicas == 0 {
+			queue = append(queue, job)
+			continue
+		}
+		if !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+			klog.Warningf("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+		}
+		allocations = append(allocations, allocation{
+			job: job,
+			end: estimatedEnd(job, now),
+		})
+	}
+
+	sort.Slice(queue, func(i, j int) bool {
+		return fcfs.jobLess(queue[i], queue[j])
+	})
+
+	// Schedule
+	var blocked *info.JobInfo
+	var shadowTime time.Time
+	var extra *session.NodePool
+	for i, job := range queue {
+		position := i + 1
+		numMasters, numReplicas := session.RequestedRoles(job)
+		if !pool.Clone().AllocateJob(job, numMasters, numReplicas) {
+			if blocked == nil {
+				if victims := pool.Preempt(job, numMasters, numReplicas, runningJobs(allocations)); victims != nil {
+					allocations = preempt(ssn, allocations, job, victims)
+					job.NumMasters = numMasters
+					job.NumReplicas = numReplicas
+					allocations = append(allocations, allocation{
+						job: job,
+						end: estimatedEnd(job, now),
+					})
+					ssn.Explainf(job, session.ReasonScheduled, "queue position %d, preempted %d jobs of lower priority",
+						position, len(victims))
+					continue
+				}
+			}
+			ssn.Explainf(job, session.ReasonInsufficientResources, "queue position %d, %s",
+				position, pool.Shortage(job, numMasters, numReplicas))
+			if !fcfs.headOfLineBlocking || blocked != nil {
+				continue
+			}
+			blocked = job
+			if !fcfs.backfill {
+				for j, waiting := range queue[i+1:] {
+					ssn.Explainf(waiting, session.ReasonQueued, "queue position %d, waiting behind job %s/%s",
+						position+j+1, job.Namespace, job.Name)
+				}
+				break
+			}
+			shadowTime, extra = reserve(pool, allocations, job, numMasters, numReplicas)
+			klog.V(4).Infof("Job <%s/%s> is blocked, reserved resources at %v", job.Namespace, job.Name, shadowTime)
+			continue
+		}
+
+		end := estimatedEnd(job, now)
+		if blocked != nil {
+			// EASY backfilling: the job must not delay the reservation of the blocked job
+			if end.After(shadowTime) || end.Equal(never) {
+				if extra == nil || !extra.AllocateJob(job, numMasters, numReplicas) {
+					ssn.Explainf(job, session.ReasonQueued, "queue position %d, cannot backfill without delaying job %s/%s",
+						position, blocked.Namespace, blocked.Name)
+					continue
+				}
+			}
+		}
+
+		job.NumMasters = numMasters
+		job.NumReplicas = numReplicas
+		pool.AllocateJob(job, numMasters, numReplicas)
+		allocations = append(allocations, allocation{
+			job: job,
+			end: end,
+		})
+		ssn.Explainf(job, session.ReasonScheduled, "queue position %d, allocated %d masters and %d replicas",
+			position, numMasters, numReplicas)
+	}
 }
 
 func (fcfs *Policy) UnInitialize() {}
+
+func (fcfs *Policy) jobLess(l, r *info.JobInfo) bool {
+	for _, less := range fcfs.less {
+		if isLess, isEqual := less(l, r); !isEqual {
+			return isLess
+		}
+	}
+	return false
+}
+
+func runningJobs(allocations []allocation) []*info.JobInfo {
+	jobs := make([]*info.JobInfo, len(allocations))
+	for i, alloc := range allocations {
+		jobs[i] = alloc.job
+	}
+	return jobs
+}
+
+// preempt stops the victims for the preemptor and returns the allocations left
+func preempt(ssn *session.Session, allocations []allocation, preemptor *info.JobInfo, victims []*info.JobInfo) []allocation {
+	for _, victim := range victims {
+		klog.V(4).Infof("Preempting job <%s/%s> of priority %d", victim.Namespace, victim.Name, victim.Priority)
+		victim.NumMasters = 0
+		victim.NumReplicas = 0
+		ssn.Explainf(victim, session.ReasonPreempted, "preempted by job %s/%s of priority %d",
+			preemptor.Namespace, preemptor.Name, preemptor.Priority)
+	}
+	remaining := allocations[:0]
+	for _, alloc := range allocations {
+		if alloc.job.NumMasters+alloc.job.NumReplicas > 0 {
+			remaining = append(remaining, alloc)
+		}
+	}
+	return remaining
+}
+
+// never is the estimated end time of jobs without an estimated runtime
+var never = time.Unix(math.MaxInt32, 0)
+
+func estimatedEnd(job *info.JobInfo, now time.Time) time.Time {
+	customFields := job.CustomFields.(*JobCustomFields)
+	if customFields.EstimatedRuntime <= 0 {
+		return never
+	}
+
+	start := now
+	if since, running := job.RunningSince(); running {
+		start = since.Time
+	}
+	end := start.Add(time.Duration(customFields.EstimatedRuntime * float64(time.Second)))
+	if end.Before(now) {
+		// Overdue jobs are expected to end any time
+		return now
+	}
+	return end
+}
+
+// reserve finds the earliest time when the requested masters and replicas of the job fit, and returns
+// the pool that will be left over at that time after reserving them
+func reserve(pool *session.NodePool, allocations []allocation, job *info.JobInfo, numMasters, numReplicas int32) (time.Time, *session.NodePool) {
+	sorted := make([]allocation, len(allocations))
+	copy(sorted, allocations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].end.Before(sorted[j].end)
+	})
+
+	future := pool.Clone()
+	for _, alloc := range sorted {
+		future.Release(alloc.job)
+		if future.AllocateJob(job, numMasters, numReplicas) {
+			return alloc.end, future
+		}
+	}
+	return never, nil
+}
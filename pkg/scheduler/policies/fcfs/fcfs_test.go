@@ -0,0 +1,176 @@
+package fcfs
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+func TestPolicy_Execute(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name      string
+		arguments session.Arguments
+		numNodes  int
+		jobs      []*info.JobInfo
+		expected  map[info.JobID]int32
+	}{
+		{
+			name:     "head-of-line blocking",
+			numNodes: 3,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0, "j3": 0},
+		},
+		{
+			name:      "no head-of-line blocking",
+			arguments: session.Arguments{"headOfLineBlocking": "false"},
+			numNodes:  3,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0, "j3": 1},
+		},
+		{
+			name:      "ordered by name",
+			arguments: session.Arguments{"orderBy": "name"},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+		},
+		{
+			name:     "higher priority preempts",
+			numNodes: 2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2"), sessiontest.WithPriority(0, true),
+					sessiontest.Running(2)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1"), sessiontest.WithPriority(1, true)),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1"), sessiontest.WithPriority(1, true)),
+			},
+			expected: map[info.JobID]int32{"j1": 0, "j2": 1, "j3": 1},
+		},
+		{
+			name:      "EASY backfilling",
+			arguments: session.Arguments{"backfill": "true"},
+			numNodes:  4,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-5*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 2, estimatedRuntime: 100}")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-4*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 3, estimatedRuntime: 100}")),
+				// Ends after the reservation of j2, but fits in the extra node
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 1, estimatedRuntime: 200}")),
+				// Ends after the reservation of j2, and no extra node is left
+				sessiontest.BuildJob("j4", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 1, estimatedRuntime: 300}")),
+				// Ends before the reservation of j2
+				sessiontest.BuildJob("j5", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 1, estimatedRuntime: 50}")),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 0, "j3": 1, "j4": 0, "j5": 1},
+		},
+	}
+
+	for _, test := range tests {
+		policy, err := New(test.arguments)
+		if err != nil {
+			t.Errorf("%s: failed to build policy: %v", test.name, err)
+			continue
+		}
+
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nil), test.jobs...)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+		policy.Execute(ssn)
+
+		for id, expected := range test.expected {
+			if ssn.Jobs[id].NumReplicas != expected {
+				t.Errorf("%s: job %v expected %d replicas, got %d", test.name, id, expected, ssn.Jobs[id].NumReplicas)
+			}
+		}
+	}
+}
+
+func TestPolicy_Explanations(t *testing.T) {
+	now := time.Unix(1000, 0)
+	policy, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", 3, nil),
+		sessiontest.BuildJob("j1", customFieldsType,
+			sessiontest.CreatedAt(now.Add(-3*time.Second)), sessiontest.WithCustomFields("numReplicas: 2")),
+		sessiontest.BuildJob("j2", customFieldsType,
+			sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithCustomFields("numReplicas: 2")),
+		sessiontest.BuildJob("j3", customFieldsType,
+			sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithCustomFields("numReplicas: 1")),
+	)
+	ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+	policy.Execute(ssn)
+
+	expected := map[info.JobID]session.Explanation{
+		"j1": {Reason: session.ReasonScheduled, Message: "queue position 1, allocated 0 masters and 2 replicas"},
+		"j2": {Reason: session.ReasonInsufficientResources, Message: "queue position 2, cluster exhausted, 1 of 2 replicas fit"},
+		"j3": {Reason: session.ReasonQueued, Message: "queue position 3, waiting behind job default/j2"},
+	}
+	for id, explanation := range expected {
+		if actual := ssn.Explanation(ssn.Jobs[id]); actual != explanation {
+			t.Errorf("Job %v: expected explanation %+v, got %+v", id, explanation, actual)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	invalidArguments := []session.Arguments{
+		{"orderBy": "size"},
+		{"headOfLineBlocking": "maybe"},
+		{"headOfLineBlocking": "false", "backfill": "true"},
+	}
+	for _, arguments := range invalidArguments {
+		if _, err := New(arguments); err == nil {
+			t.Errorf("Expected error building policy with arguments %v", arguments)
+		}
+	}
+}
@@ -1,31 +1,31 @@
 package hell
 
 import (
-	"bytes"
 	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
-	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/profiling"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
-	v1 "k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/klog"
 	"math"
 	"reflect"
-	"strconv"
+	"sort"
+	"time"
 )
 
-type JobCustomFields struct {
-	BatchSize  int       `yaml:"batchSize"`
-	Iterations int       `yaml:"iterations"`
-	Throughput []float64 `yaml:"throughput"`
+type JobCustomFields = profiling.JobCustomFields
 
-	CompletedIterations int
-}
+// JobState is kept for each job between sessions
+type JobState = profiling.JobState
 
-type Policy struct{}
+type Policy struct {
+	estimator profiling.Estimator
+}
 
-func New() *Policy {
-	return &Policy{}
+func New(arguments session.Arguments) (session.Policy, error) {
+	estimator, err := profiling.NewEstimator(arguments)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{estimator: estimator}, nil
 }
 
 func (hell *Policy) Name() string {
@@ -36,59 +36,29 @@ func (hell *Policy) JobCustomFieldsType() reflect.Type {
 	return reflect.TypeOf((*JobCustomFields)(nil))
 }
 
+func (hell *Policy) JobStateType() reflect.Type {
+	return reflect.TypeOf((*JobState)(nil))
+}
+
 func (hell *Policy) Initialize() {}
 
 func (hell *Policy) Execute(ssn *session.Session) {
 	klog.V(3).Infof("Begin HELL")
 	defer klog.V(3).Infof("End HELL")
 
-	// Get # completed iterations reported by the job
+	// Profile the throughput with the allocation the jobs ran with
 	for _, job := range ssn.Jobs {
-		var podName string
-		switch job.Type {
-		case pintav1.Symmetric:
-			podName = job.Name + "-replica-0"
-		case pintav1.PSWorker:
-			podName = job.Name + "-worker-0"
-		case pintav1.MPI:
-			podName = job.Name + "-replica-0"
-		default:
-			continue
-		}
-		clientset := ssn.KubeClient()
-		req := clientset.CoreV1().RESTClient().Post().Resource("pods").
-			Name(podName).
-			Namespace(job.Namespace).SubResource("exec")
-		req.VersionedParams(&v1.PodExecOptions{
-			Command: []string{"cat", "/etc/pinta/ITERATION"},
-			Stdin:   false,
-			Stdout:  true,
-			Stderr:  false,
-			TTY:     false,
-		}, scheme.ParameterCodec)
-
-		var stdout bytes.Buffer
-		exec, err := remotecommand.NewSPDYExecutor(ssn.KubeConfig(), "POST", req.URL())
-		if err != nil {
-			continue
-		}
-		err = exec.Stream(remotecommand.StreamOptions{
-			Stdin:  nil,
-			Stdout: &stdout,
-			Stderr: nil,
-		})
-		customFields := job.CustomFields.(*JobCustomFields)
-		stdoutString := stdout.String()
-		customFields.CompletedIterations, err = strconv.Atoi(stdoutString)
-		if err != nil {
-			continue
-		}
+		job.State.(*JobState).Profile.Observe(job)
 	}
 
-	// Clear previous schedules
-	for _, job := range ssn.Jobs {
-		job.NumMasters = 0
-		job.NumReplicas = 0
+	// Clear previous schedules of the jobs that can be preempted
+	pool := session.NewNodePool(ssn)
+	kept := pool.KeepNonPreemptible(ssn.Jobs)
+	for id, job := range ssn.Jobs {
+		if !kept[id] {
+			job.NumMasters = 0
+			job.NumReplicas = 0
+		}
 	}
 
 	// Calculate ratios
@@ -96,9 +66,9 @@ func (hell *Policy) Execute(ssn *session.Session) {
 	remainingServiceTimesMap := make(map[info.JobID][]float64) // Fill based on remaining service times
 	for id, job := range ssn.Jobs {
 		customFields := job.CustomFields.(*JobCustomFields)
-		throughput := customFields.Throughput
-		remainingIterations := customFields.Iterations - customFields.CompletedIterations
-		remainingExamples := remainingIterations * customFields.BatchSize
+		throughput := hell.estimator.Throughput(job, len(ssn.Nodes))
+		remainingIterations := customFields.Iterations - job.CompletedIterations()
+		remainingExamples := remainingIterations * customFields.ExamplesPerIteration()
 		remainingServiceTimes := make([]float64, len(throughput))
 		remainingServiceTimesMap[id] = remainingServiceTimes
 		ratios := make([]float64, len(throughput))
@@ -110,21 +80,33 @@ func (hell *Policy) Execute(ssn *session.Session) {
 			ratios[i] = remainingServiceTimes[i] / efficiency
 		}
 	}
+	// Jobs kept running only take more replicas in the fill
+	for id := range kept {
+		delete(ratiosMap, id)
+	}
 
-	// Schedule
-	numNodes := len(ssn.Nodes)
-	for numNodes > 0 && len(ratiosMap) > 0 {
+	// Schedule, jobs of higher priority first
+	var lastJob *info.JobInfo
+	lastRatio := 0.0
+	for len(ratiosMap) > 0 {
 		// Pick the job with minimum ratio
 		var nextJob *info.JobInfo
 		optimalNumReplicas := 0
 		minRatio := math.MaxFloat64
 		for id, ratios := range ratiosMap {
 			job := ssn.Jobs[id]
-			numMasters := 0
-			if job.Type == pintav1.PSWorker || job.Type == pintav1.MPI {
-				numMasters = 1
+			maxReplicas := pool.MaxReplicas(job, job.MinMasters)
+			if maxReplicas > int(job.MaxReplicas) {
+				maxReplicas = int(job.MaxReplicas)
 			}
-			for i := 0; i < numNodes-numMasters && i < len(ratios); i++ {
+			if maxReplicas < int(job.MinReplicas) || (nextJob != nil && job.Priority < nextJob.Priority) {
+				continue
+			}
+			if nextJob != nil && job.Priority > nextJob.Priority {
+				nextJob = nil
+				minRatio = math.MaxFloat64
+			}
+			for i := int(job.MinReplicas) - 1; i < maxReplicas && i < len(ratios); i++ {
 				change := false
 				if ratios[i] < minRatio {
 					change = true
@@ -150,21 +132,50 @@ func (hell *Policy) Execute(ssn *session.Session) {
 			}
 		}
 		if nextJob == nil {
-			klog.Errorf("No PintaJob to schedule: %d node available", numNodes)
+			klog.V(3).Infof("No PintaJob fits in the remaining resources")
 			break
 		}
 		// Schedule
-		if nextJob.Type == pintav1.PSWorker || nextJob.Type == pintav1.MPI {
-			nextJob.NumMasters = 1
-		}
+		nextJob.NumMasters = nextJob.MinMasters
 		nextJob.NumReplicas = int32(optimalNumReplicas)
-		numNodes -= int(nextJob.NumMasters + nextJob.NumReplicas)
+		pool.AllocateJob(nextJob, nextJob.NumMasters, nextJob.NumReplicas)
 		delete(ratiosMap, nextJob.UID)
+		ssn.Explainf(nextJob, session.ReasonScheduled, "ratio %.1f at %d replicas", minRatio, optimalNumReplicas)
+		lastJob = nextJob
+		lastRatio = minRatio
+	}
+	var waiting []*info.JobInfo
+	minRatios := make(map[info.JobID]float64, len(ratiosMap))
+	for id, ratios := range ratiosMap {
+		job := ssn.Jobs[id]
+		waiting = append(waiting, job)
+		minRatios[id] = minOf(ratios)
+		shortage := pool.Shortage(job, job.MinMasters, job.MinReplicas)
+		if lastJob == nil {
+			ssn.Explainf(job, session.ReasonInsufficientResources, "%s", shortage)
+			continue
+		}
+		ssn.Explainf(job, session.ReasonOutranked, "ratio %.1f lost to job %s/%s of ratio %.1f, %s",
+			minRatios[id], lastJob.Namespace, lastJob.Name, lastRatio, shortage)
 	}
 	ratiosMap = nil
+	sort.Slice(waiting, func(i, j int) bool {
+		l, r := waiting[i], waiting[j]
+		if l.Priority != r.Priority {
+			return l.Priority > r.Priority
+		}
+		if minRatios[l.UID] != minRatios[r.UID] {
+			return minRatios[l.UID] < minRatios[r.UID]
+		}
+		return l.UID < r.UID
+	})
+	serviceTimesMap := make(map[info.JobID][]float64, len(remainingServiceTimesMap))
+	for id, remainingServiceTimes := range remainingServiceTimesMap {
+		serviceTimesMap[id] = remainingServiceTimes
+	}
 
 	// Fill
-	for numNodes > 0 && len(remainingServiceTimesMap) > 0 {
+	for len(remainingServiceTimesMap) > 0 {
 		// Pick the job with min # replicas to achieve min remaining service time
 		minAdditionalNumReplicasToAchieveMinRemainingServiceTime := math.MaxInt32
 		var nextJob *info.JobInfo
@@ -176,7 +187,11 @@ func (hell *Policy) Execute(ssn *session.Session) {
 			}
 			var numAdditionalReplicasToAchieveMinRemainingServiceTime int
 			minRemainingServiceTime := math.MaxFloat64
-			for additionalNodes := 0; additionalNodes <= numNodes; additionalNodes++ {
+			maxAdditionalReplicas := pool.MaxReplicas(job, 0)
+			if maxAdditionalReplicas > int(job.MaxReplicas-job.NumReplicas) {
+				maxAdditionalReplicas = int(job.MaxReplicas - job.NumReplicas)
+			}
+			for additionalNodes := 0; additionalNodes <= maxAdditionalReplicas; additionalNodes++ {
 				if int(job.NumReplicas)+additionalNodes > len(remainingServiceTimes) {
 					break
 				}
@@ -186,7 +201,9 @@ func (hell *Policy) Execute(ssn *session.Session) {
 				}
 			}
 			change := false
-			if numAdditionalReplicasToAchieveMinRemainingServiceTime < minAdditionalNumReplicasToAchieveMinRemainingServiceTime {
+			if nextJob != nil && job.Priority != nextJob.Priority {
+				change = job.Priority > nextJob.Priority
+			} else if numAdditionalReplicasToAchieveMinRemainingServiceTime < minAdditionalNumReplicasToAchieveMinRemainingServiceTime {
 				change = true
 			} else if numAdditionalReplicasToAchieveMinRemainingServiceTime == minAdditionalNumReplicasToAchieveMinRemainingServiceTime {
 				if nextJob == nil {
@@ -213,10 +230,90 @@ func (hell *Policy) Execute(ssn *session.Session) {
 		}
 
 		nextJob.NumReplicas += int32(minAdditionalNumReplicasToAchieveMinRemainingServiceTime)
-		numNodes -= minAdditionalNumReplicasToAchieveMinRemainingServiceTime
+		pool.AllocateJob(nextJob, 0, int32(minAdditionalNumReplicasToAchieveMinRemainingServiceTime))
 		delete(remainingServiceTimesMap, nextJob.UID)
 	}
 	remainingServiceTimesMap = nil
+
+	estimate(ssn, pool, serviceTimesMap, waiting)
+}
+
+// completion is the estimated time a job completes at
+type completion struct {
+	job *info.JobInfo
+	at  time.Time
+}
+
+// estimate sets the estimated completion times of the jobs allocated replicas from their remaining
+// service times, and the estimated start times of the waiting jobs. The waiting jobs are assumed to start
+// in order with their min # masters and replicas as soon as the jobs completing before free enough
+// resources. Jobs that depend on jobs of unknown # iterations are not estimated.
+func estimate(ssn *session.Session, pool *session.NodePool, remainingServiceTimesMap map[info.JobID][]float64, waiting []*info.JobInfo) {
+	now := ssn.Now()
+	var completions []completion
+	for _, job := range ssn.Jobs {
+		if job.NumReplicas == 0 {
+			continue
+		}
+		if at, ok := completionTime(job, now, remainingServiceTimesMap[job.UID], job.NumReplicas); ok {
+			ssn.EstimateCompletion(job, at)
+			completions = append(completions, completion{job: job, at: at})
+		}
+	}
+
+	future := pool.Clone()
+	clock := now
+	for _, job := range waiting {
+		sort.Slice(completions, func(i, j int) bool {
+			return completions[i].at.Before(completions[j].at)
+		})
+		fits := future.AllocateJob(job, job.MinMasters, job.MinReplicas)
+		for !fits && len(completions) > 0 {
+			future.Release(completions[0].job)
+			if completions[0].at.After(clock) {
+				clock = completions[0].at
+			}
+			completions = completions[1:]
+			fits = future.AllocateJob(job, job.MinMasters, job.MinReplicas)
+		}
+		if !fits {
+			// Nor are the jobs after it, which start no earlier
+			return
+		}
+		ssn.EstimateStart(job, clock)
+		if at, ok := completionTime(job, clock, remainingServiceTimesMap[job.UID], job.MinReplicas); ok {
+			completions = append(completions, completion{job: job, at: at})
+		}
+	}
+}
+
+// maxEstimate bounds the estimates, beyond which they are meaningless
+const maxEstimate = 365 * 24 * time.Hour
+
+// completionTime returns when the job started at start completes with numReplicas replicas, and whether
+// it can be estimated
+func completionTime(job *info.JobInfo, start time.Time, remainingServiceTimes []float64, numReplicas int32) (time.Time, bool) {
+	if job.CustomFields.(*JobCustomFields).Iterations <= 0 || len(remainingServiceTimes) == 0 {
+		return time.Time{}, false
+	}
+	n := int(numReplicas)
+	if n > len(remainingServiceTimes) {
+		n = len(remainingServiceTimes)
+	}
+	remaining := math.Max(remainingServiceTimes[n-1], 0)
+	if remaining > maxEstimate.Seconds() {
+		return time.Time{}, false
+	}
+	return start.Add(time.Duration(remaining * float64(time.Second))), true
 }
 
 func (hell *Policy) UnInitialize() {}
+
+// minOf returns the min of the values, which must not be empty
+func minOf(values []float64) float64 {
+	min := values[0]
+	for _, value := range values[1:] {
+		min = math.Min(min, value)
+	}
+	return min
+}
@@ -0,0 +1,107 @@
+package hell
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+func TestPolicy_Execute_NodeTypes(t *testing.T) {
+	customFieldsStr := `
+batchSize: 1
+iterations: 100
+throughput: [10, 20, 30, 40]
+`
+	tests := []struct {
+		name           string
+		numNodesByType map[string]int
+		jobs           []*info.JobInfo
+		expected       map[info.JobID]int32
+	}{
+		{
+			name:           "jobs pinned to node types",
+			numNodesByType: map[string]int{"cpu": 3, "gpu": 1},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.WithReplicaNodeType("gpu"), sessiontest.WithCustomFields(customFieldsStr)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithCustomFields(customFieldsStr)),
+			},
+			expected: map[info.JobID]int32{"j1": 1, "j2": 3},
+		},
+		{
+			name:           "node type exhausted",
+			numNodesByType: map[string]int{"cpu": 2},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.WithReplicaNodeType("gpu"), sessiontest.WithCustomFields(customFieldsStr)),
+				sessiontest.BuildJob("j2", customFieldsType, sessiontest.WithCustomFields(customFieldsStr)),
+			},
+			expected: map[info.JobID]int32{"j1": 0, "j2": 2},
+		},
+		{
+			name:           "job without throughput is profiled on a single replica",
+			numNodesByType: map[string]int{"cpu": 3},
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.WithReplicaNodeType("cpu"), sessiontest.WithCustomFields("iterations: 100")),
+			},
+			expected: map[info.JobID]int32{"j1": 1},
+		},
+	}
+
+	policy, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	for _, test := range tests {
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodesByType(test.numNodesByType), test.jobs...)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, time.Now())
+		session.ExecutePolicies(ssn, []session.Policy{policy})
+
+		for id, expected := range test.expected {
+			if ssn.Jobs[id].NumReplicas != expected {
+				t.Errorf("%s: job %v expected %d replicas, got %d", test.name, id, expected, ssn.Jobs[id].NumReplicas)
+			}
+		}
+	}
+}
+
+func TestPolicy_Estimates(t *testing.T) {
+	customFieldsStr := `
+batchSize: 1
+iterations: 100
+throughput: [10, 20]
+`
+	policy, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	now := time.Unix(1000, 0)
+	snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodesByType(map[string]int{"cpu": 2}),
+		sessiontest.BuildJob("j1", customFieldsType, sessiontest.WithCustomFields(customFieldsStr)),
+		sessiontest.BuildJob("j2", customFieldsType, sessiontest.WithCustomFields(customFieldsStr)),
+		// Jobs of unknown length are never expected to complete
+		sessiontest.BuildJob("j3", customFieldsType, sessiontest.WithCustomFields("throughput: [1]")),
+	)
+	snapshot.Jobs["j3"].Priority = -1
+	ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+	session.ExecutePolicies(ssn, []session.Policy{policy})
+
+	// j1 runs on both nodes for 5s, then j2 and j3 of lower priority start with a single replica each
+	expected := map[info.JobID]session.Estimate{
+		"j1": {Completion: now.Add(5 * time.Second)},
+		"j2": {Start: now.Add(5 * time.Second)},
+		"j3": {Start: now.Add(5 * time.Second)},
+	}
+	if actual := ssn.Estimates(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected estimates %+v, got %+v", expected, actual)
+	}
+}
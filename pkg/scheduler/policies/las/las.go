@@ -0,0 +1,160 @@
+package las
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"k8s.io/klog"
+	"reflect"
+	"sort"
+)
+
+const (
+	// queueThresholdsKey is the increasing list of attained service, in replica-seconds, at which jobs are
+	// demoted to the next queue
+	queueThresholdsKey = "queueThresholds"
+	// promoteAfterKey is the # seconds a job may wait before it is promoted back to the first queue, or 0
+	// to never promote jobs
+	promoteAfterKey = "promoteAfter"
+)
+
+type JobCustomFields = session.RoleCustomFields
+
+// JobState is kept for each job between sessions
+type JobState struct {
+	// AttainedService is the # replica-seconds the job has run for, masters included
+	AttainedService float64 `yaml:"attainedService"`
+
+	// The last time the job was seen by the policy
+	Seen     bool    `yaml:"seen"`
+	LastSeen float64 `yaml:"lastSeen"`
+
+	// The time since when the job has been waiting without resources
+	Waiting      bool    `yaml:"waiting"`
+	WaitingSince float64 `yaml:"waitingSince"`
+}
+
+// Policy is a discretized least-attained-service policy in the style of Tiresias. Jobs are put in
+// queues by the service they have attained so far, and the queues are served in order, first come
+// first served within a queue. Jobs that have run longest thus give way to newcomers without knowing
+// the length of any job.
+//
+// Jobs of higher priority are served before all the queues. Running jobs that cannot be preempted keep
+// their resources.
+type Policy struct {
+	queueThresholds []float64
+	promoteAfter    float64
+}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	las := &Policy{
+		queueThresholds: []float64{3600},
+	}
+	if err := arguments.GetFloat64List(&las.queueThresholds, queueThresholdsKey); err != nil {
+		return nil, err
+	}
+	for i, threshold := range las.queueThresholds {
+		if threshold <= 0 || (i > 0 && threshold <= las.queueThresholds[i-1]) {
+			return nil, fmt.Errorf("argument %s must be positive and increasing", queueThresholdsKey)
+		}
+	}
+	if err := arguments.GetFloat64(&las.promoteAfter, promoteAfterKey); err != nil {
+		return nil, err
+	}
+	if las.promoteAfter < 0 {
+		return nil, fmt.Errorf("argument %s must not be negative", promoteAfterKey)
+	}
+	return las, nil
+}
+
+func (las *Policy) Name() string {
+	return "las"
+}
+
+func (las *Policy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*JobCustomFields)(nil))
+}
+
+func (las *Policy) JobStateType() reflect.Type {
+	return reflect.TypeOf((*JobState)(nil))
+}
+
+func (las *Policy) Initialize() {}
+
+func (las *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin LAS")
+	defer klog.V(3).Infof("End LAS")
+
+	now := float64(ssn.Now().UnixNano()) / 1e9
+
+	// Account the service attained with the allocation the jobs ran with since the last session, and
+	// promote starved jobs
+	jobs := make([]*info.JobInfo, 0, len(ssn.Jobs))
+	queues := make(map[info.JobID]int, len(ssn.Jobs))
+	for _, job := range ssn.Jobs {
+		state := job.State.(*JobState)
+		if state.Seen && now > state.LastSeen {
+			state.AttainedService += float64(job.NumMasters+job.NumReplicas) * (now - state.LastSeen)
+		}
+		state.Seen = true
+		state.LastSeen = now
+
+		if las.promoteAfter > 0 && state.Waiting && now-state.WaitingSince >= las.promoteAfter {
+			klog.V(4).Infof("Job <%s/%s> waited since %v, promoting it", job.Namespace, job.Name, state.WaitingSince)
+			state.AttainedService = 0
+			state.WaitingSince = now
+		}
+
+		jobs = append(jobs, job)
+		queues[job.UID] = las.queue(state.AttainedService)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		l, r := jobs[i], jobs[j]
+		if l.Priority != r.Priority {
+			return l.Priority > r.Priority
+		}
+		if queues[l.UID] != queues[r.UID] {
+			return queues[l.UID] < queues[r.UID]
+		}
+		return session.ArrivedBefore(l, r)
+	})
+
+	// Allocate from scratch in order of the queues, preempting the jobs that no longer fit unless they
+	// cannot be preempted
+	pool := session.NewNodePool(ssn)
+	kept := pool.KeepNonPreemptible(ssn.Jobs)
+	for i, job := range jobs {
+		if !kept[job.UID] {
+			numMasters, numReplicas := session.RequestedRoles(job)
+			if pool.AllocateJob(job, numMasters, numReplicas) {
+				job.NumMasters = numMasters
+				job.NumReplicas = numReplicas
+				ssn.Explainf(job, session.ReasonScheduled, "position %d, in queue %d by attained service of %.0fs",
+					i+1, queues[job.UID], job.State.(*JobState).AttainedService)
+			} else {
+				ssn.Explainf(job, session.ReasonInsufficientResources, "position %d, in queue %d, %s",
+					i+1, queues[job.UID], pool.Shortage(job, numMasters, numReplicas))
+				job.NumMasters = 0
+				job.NumReplicas = 0
+			}
+		}
+
+		state := job.State.(*JobState)
+		if job.NumMasters+job.NumReplicas > 0 {
+			state.Waiting = false
+		} else if !state.Waiting {
+			state.Waiting = true
+			state.WaitingSince = now
+		}
+	}
+}
+
+func (las *Policy) UnInitialize() {}
+
+// queue returns the index of the queue of a job with the attained service
+func (las *Policy) queue(attainedService float64) int {
+	return sort.Search(len(las.queueThresholds), func(i int) bool {
+		return attainedService < las.queueThresholds[i]
+	})
+}
@@ -0,0 +1,185 @@
+package las
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// step is a session at some time after start, with the jobs and the # replicas they run with
+type step struct {
+	after    time.Duration
+	jobs     []*info.JobInfo
+	expected map[info.JobID]int32
+}
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+func TestPolicy_Execute(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	tests := []struct {
+		name      string
+		arguments session.Arguments
+		numNodes  int
+		states    map[info.JobID]string
+		steps     []step
+	}{
+		{
+			name:      "newcomer preempts long-running job",
+			arguments: session.Arguments{"queueThresholds": "100"},
+			numNodes:  2,
+			steps: []step{
+				{
+					after: 0,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-10*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 2},
+				},
+				{
+					// j1 attained 40 replica-seconds and stays in the first queue
+					after: 20 * time.Second,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-10*time.Second)), sessiontest.Running(2),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+						sessiontest.BuildJob("j2", customFieldsType,
+							sessiontest.CreatedAt(start.Add(10*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+				},
+				{
+					// j1 attained 200 replica-seconds and is demoted
+					after: 100 * time.Second,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-10*time.Second)), sessiontest.Running(2),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+						sessiontest.BuildJob("j2", customFieldsType,
+							sessiontest.CreatedAt(start.Add(10*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 0, "j2": 2},
+				},
+			},
+		},
+		{
+			name:      "jobs fill the gaps left by jobs of higher priority",
+			arguments: session.Arguments{"queueThresholds": "100"},
+			numNodes:  3,
+			states: map[info.JobID]string{
+				"j1": "attainedService: 1000",
+			},
+			steps: []step{
+				{
+					after: 0,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-3*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 1")),
+						sessiontest.BuildJob("j2", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-2*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+						sessiontest.BuildJob("j3", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-1*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 1, "j2": 2, "j3": 0},
+				},
+			},
+		},
+		{
+			name:      "starved job is promoted",
+			arguments: session.Arguments{"queueThresholds": "100000", "promoteAfter": "300"},
+			numNodes:  2,
+			states: map[info.JobID]string{
+				"j1": "attainedService: 200000",
+			},
+			steps: []step{
+				{
+					after: 0,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-20*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+						sessiontest.BuildJob("j2", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-10*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 0, "j2": 2},
+				},
+				{
+					after: 299 * time.Second,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-20*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+						sessiontest.BuildJob("j2", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-10*time.Second)), sessiontest.Running(2),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 0, "j2": 2},
+				},
+				{
+					after: 300 * time.Second,
+					jobs: []*info.JobInfo{
+						sessiontest.BuildJob("j1", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-20*time.Second)),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+						sessiontest.BuildJob("j2", customFieldsType,
+							sessiontest.CreatedAt(start.Add(-10*time.Second)), sessiontest.Running(2),
+							sessiontest.WithCustomFields("numReplicas: 2")),
+					},
+					expected: map[info.JobID]int32{"j1": 2, "j2": 0},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		policy, err := New(test.arguments)
+		if err != nil {
+			t.Errorf("%s: failed to build policy: %v", test.name, err)
+			continue
+		}
+
+		store := cache.NewMemoryJobStateStore()
+		for id, state := range test.states {
+			store.Set(id, policy.Name(), state)
+		}
+
+		for _, step := range test.steps {
+			snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nil), step.jobs...)
+			ssn := session.OpenSessionWithSnapshot(snapshot, store, start.Add(step.after))
+			session.ExecutePolicies(ssn, []session.Policy{policy})
+
+			for id, expected := range step.expected {
+				if ssn.Jobs[id].NumReplicas != expected {
+					t.Errorf("%s: job %v expected %d replicas after %v, got %d",
+						test.name, id, expected, step.after, ssn.Jobs[id].NumReplicas)
+				}
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	invalidArguments := []session.Arguments{
+		{"queueThresholds": "0"},
+		{"queueThresholds": "100,10"},
+		{"promoteAfter": "-1"},
+	}
+	for _, arguments := range invalidArguments {
+		if _, err := New(arguments); err == nil {
+			t.Errorf("Expected error building policy with arguments %v", arguments)
+		}
+	}
+}
@@ -12,8 +12,8 @@ type JobCustomFields struct {
 
 type Policy struct{}
 
-func New() *Policy {
-	return &Policy{}
+func New(arguments session.Arguments) (session.Policy, error) {
+	return &Policy{}, nil
 }
 
 func (nop *Policy) Name() string {
@@ -27,11 +27,17 @@ func (hell *Policy) JobCustomFieldsType() reflect.Type {
 func (nop *Policy) Initialize() {}
 
 func (nop *Policy) Execute(ssn *session.Session) {
-	// Job spec passthrough
+	// Job spec passthrough, within the bounds of the jobs
 	for _, job := range ssn.Jobs {
 		customFields := job.CustomFields.(*JobCustomFields)
-		job.NumMasters = customFields.NumMasters
-		job.NumReplicas = customFields.NumReplicas
+		if customFields.NumMasters == 0 && customFields.NumReplicas == 0 {
+			job.NumMasters = 0
+			job.NumReplicas = 0
+			ssn.Explainf(job, session.ReasonNotScheduled, "no masters or replicas requested")
+			continue
+		}
+		job.NumMasters = job.FitMasters(customFields.NumMasters)
+		job.NumReplicas = job.FitReplicas(customFields.NumReplicas)
 	}
 }
 
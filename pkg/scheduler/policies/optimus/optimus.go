@@ -0,0 +1,142 @@
+package optimus
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/profiling"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"k8s.io/klog"
+	"reflect"
+	"sort"
+)
+
+type JobCustomFields = profiling.JobCustomFields
+
+// JobState is kept for each job between sessions
+type JobState = profiling.JobState
+
+// Policy is a greedy allocator in the style of Optimus. Every job that fits gets a single replica
+// first, then replicas are added one at a time to the job whose estimated remaining time decreases most
+// with it, until no job gains from another replica.
+//
+// Jobs of higher priority get their replicas before the others, and running jobs that cannot be
+// preempted keep their resources.
+type Policy struct {
+	estimator profiling.Estimator
+}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	estimator, err := profiling.NewEstimator(arguments)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{estimator: estimator}, nil
+}
+
+func (optimus *Policy) Name() string {
+	return "optimus"
+}
+
+func (optimus *Policy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*JobCustomFields)(nil))
+}
+
+func (optimus *Policy) JobStateType() reflect.Type {
+	return reflect.TypeOf((*JobState)(nil))
+}
+
+func (optimus *Policy) Initialize() {}
+
+func (optimus *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin Optimus")
+	defer klog.V(3).Infof("End Optimus")
+
+	// Profile the throughput with the allocation the jobs ran with
+	for _, job := range ssn.Jobs {
+		job.State.(*JobState).Profile.Observe(job)
+	}
+
+	// Clear previous schedules of the jobs that can be preempted and estimate the remaining time of each
+	// job at each # replicas
+	pool := session.NewNodePool(ssn)
+	kept := pool.KeepNonPreemptible(ssn.Jobs)
+	jobs := make([]*info.JobInfo, 0, len(ssn.Jobs))
+	remainingTimesMap := make(map[info.JobID][]float64, len(ssn.Jobs))
+	for id, job := range ssn.Jobs {
+		if !kept[id] {
+			job.NumMasters = 0
+			job.NumReplicas = 0
+		}
+		jobs = append(jobs, job)
+
+		customFields := job.CustomFields.(*JobCustomFields)
+		throughput := optimus.estimator.Throughput(job, len(ssn.Nodes))
+		remainingIterations := customFields.Iterations - job.CompletedIterations()
+		if remainingIterations < 0 {
+			remainingIterations = 0
+		}
+		remainingExamples := float64(remainingIterations * customFields.ExamplesPerIteration())
+		remainingTimes := make([]float64, len(throughput))
+		for i := range throughput {
+			remainingTimes[i] = remainingExamples / throughput[i]
+		}
+		remainingTimesMap[id] = remainingTimes
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return session.JobLess(jobs[i], jobs[j])
+	})
+
+	// A job makes no progress without a replica, so every job that fits gets its min # replicas first
+	for _, job := range jobs {
+		if kept[job.UID] {
+			continue
+		}
+		if pool.AllocateJob(job, job.MinMasters, job.MinReplicas) {
+			job.NumMasters = job.MinMasters
+			job.NumReplicas = job.MinReplicas
+		} else {
+			ssn.Explainf(job, session.ReasonInsufficientResources, "%s",
+				pool.Shortage(job, job.MinMasters, job.MinReplicas))
+		}
+	}
+
+	// Add replicas one at a time to the job with the largest marginal gain among the jobs of the highest
+	// priority that gain. Jobs that cannot fit one more replica never do later, as the pool only fills up.
+	full := make(map[info.JobID]bool)
+	for {
+		var nextJob *info.JobInfo
+		maxGain := 0.0
+		for _, job := range jobs {
+			remainingTimes := remainingTimesMap[job.UID]
+			n := int(job.NumReplicas)
+			if n == 0 || n >= len(remainingTimes) || n >= int(job.MaxReplicas) || full[job.UID] {
+				continue
+			}
+			if nextJob != nil && job.Priority < nextJob.Priority {
+				// Jobs are in order of priority
+				break
+			}
+			// Jobs are in order, so ties go to the oldest job
+			if gain := remainingTimes[n-1] - remainingTimes[n]; gain > maxGain {
+				nextJob = job
+				maxGain = gain
+			}
+		}
+		if nextJob == nil {
+			break
+		}
+		if !pool.AllocateJob(nextJob, 0, 1) {
+			full[nextJob.UID] = true
+			continue
+		}
+		nextJob.NumReplicas++
+	}
+
+	for _, job := range jobs {
+		remainingTimes := remainingTimesMap[job.UID]
+		if n := int(job.NumReplicas); n > 0 && n <= len(remainingTimes) {
+			ssn.Explainf(job, session.ReasonScheduled, "%d replicas, estimated %.0fs remaining", n, remainingTimes[n-1])
+		}
+	}
+}
+
+func (optimus *Policy) UnInitialize() {}
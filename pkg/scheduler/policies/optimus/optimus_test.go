@@ -0,0 +1,116 @@
+package optimus
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+func TestPolicy_Execute(t *testing.T) {
+	now := time.Unix(1000, 0)
+	// Remaining times of 100, 50, 33.3 and 25 seconds
+	linear := "{iterations: 100, throughput: [1, 2, 3, 4]}"
+	// Remaining times of 100, 90.9, 90.9 and 90.9 seconds
+	flat := "{iterations: 100, throughput: [1, 1.1, 1.1, 1.1]}"
+
+	tests := []struct {
+		name     string
+		numNodes int
+		jobs     []*info.JobInfo
+		expected map[info.JobID]int32
+	}{
+		{
+			name:     "largest marginal gain first",
+			numNodes: 4,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithCustomFields(linear)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithCustomFields(flat)),
+			},
+			expected: map[info.JobID]int32{"j1": 3, "j2": 1},
+		},
+		{
+			name:     "gain of a scalable job drops below the one of a flat job",
+			numNodes: 5,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithCustomFields(linear)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithCustomFields(flat)),
+			},
+			expected: map[info.JobID]int32{"j1": 3, "j2": 2},
+		},
+		{
+			name:     "stops without gain",
+			numNodes: 8,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithCustomFields(linear)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithCustomFields(flat)),
+			},
+			expected: map[info.JobID]int32{"j1": 4, "j2": 2},
+		},
+		{
+			name:     "oldest jobs get a replica first",
+			numNodes: 2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-3*time.Second)), sessiontest.WithCustomFields(flat)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithCustomFields(linear)),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithCustomFields(linear)),
+			},
+			expected: map[info.JobID]int32{"j1": 1, "j2": 1, "j3": 0},
+		},
+		{
+			name:     "replicas within the bounds of the jobs",
+			numNodes: 5,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-2*time.Second)), sessiontest.WithCustomFields(linear),
+					sessiontest.WithReplicaBounds(1, 2)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.CreatedAt(now.Add(-1*time.Second)), sessiontest.WithCustomFields(flat),
+					sessiontest.WithReplicaBounds(2, 4)),
+			},
+			expected: map[info.JobID]int32{"j1": 2, "j2": 2},
+		},
+	}
+
+	policy, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	for _, test := range tests {
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nil), test.jobs...)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+		session.ExecutePolicies(ssn, []session.Policy{policy})
+
+		for id, expected := range test.expected {
+			if ssn.Jobs[id].NumReplicas != expected {
+				t.Errorf("%s: job %v expected %d replicas, got %d", test.name, id, expected, ssn.Jobs[id].NumReplicas)
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	invalidArguments := []session.Arguments{
+		{"defaultThroughput": "1,0"},
+		{"throughputModel": "linear"},
+	}
+	for _, arguments := range invalidArguments {
+		if _, err := New(arguments); err == nil {
+			t.Errorf("Expected error building policy with arguments %v", arguments)
+		}
+	}
+}
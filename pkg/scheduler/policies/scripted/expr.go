@@ -0,0 +1,731 @@
+package scripted
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expressions are written in a small language in the style of CEL. Values are numbers (float64), strings,
+// bools, null, lists and maps with string keys. It has
+//  - literals: 1, 2.5, "prod", 'prod', true, false, null and lists such as ["a", "b"]
+//  - variables given when the expression is compiled, fields a.b and indices a["b"] or a[0]. Fields and
+//    keys that are missing, or accessed on null, are null.
+//  - operators, from the lowest precedence: c ? a : b, ||, &&, == != < <= > >= in, + -, * / %, ! and -
+//  - functions size(x), min(a, b), max(a, b) and methods s.startsWith(t), s.endsWith(t), s.contains(t)
+// Operands of the wrong type are errors when the expression is evaluated, except for == and !=, where
+// values of different types are not equal.
+
+// Expression is a compiled expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses the source into an expression over the variables
+func Compile(source string, variables []string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", source, err)
+	}
+	p := &parser{tokens: tokens, variables: map[string]bool{}}
+	for _, variable := range variables {
+		p.variables[variable] = true
+	}
+	root, err := p.parseTernary()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("at %d: unexpected %s", p.peek().pos, p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", source, err)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// Evaluate evaluates the expression with the values of the variables
+func (e *Expression) Evaluate(variables map[string]interface{}) (interface{}, error) {
+	value, err := e.root.eval(variables)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", e.source, err)
+	}
+	return value, nil
+}
+
+// EvaluateBool evaluates the expression, which must result in a bool
+func (e *Expression) EvaluateBool(variables map[string]interface{}) (bool, error) {
+	value, err := e.Evaluate(variables)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q: expected bool, got %s", e.source, typeName(value))
+	}
+	return b, nil
+}
+
+// EvaluateNumber evaluates the expression, which must result in a number
+func (e *Expression) EvaluateNumber(variables map[string]interface{}) (float64, error) {
+	value, err := e.Evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+	f, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("expression %q: expected number, got %s", e.source, typeName(value))
+	}
+	return f, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// value is the value of number and string literals
+	value interface{}
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators are the operators and punctuation, longest first so that e.g. <= is not read as <
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "+", "-", "*", "/", "%", "!", "?", ":", ".", ",", "(", ")", "[", "]",
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			f, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("at %d: invalid number %q", start, source[start:i])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], value: f, pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(source) {
+					return nil, fmt.Errorf("at %d: unterminated string", start)
+				}
+				if rune(source[i]) == c {
+					i++
+					break
+				}
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+					switch source[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(source[i])
+					}
+					continue
+				}
+				b.WriteByte(source[i])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: source[start:i], value: b.String(), pos: start})
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(source) && (source[i] == '_' || unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+		default:
+			found := false
+			for _, operator := range operators {
+				if strings.HasPrefix(source[i:], operator) {
+					tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: i})
+					i += len(operator)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("at %d: unexpected character %q", i, c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+type parser struct {
+	tokens    []token
+	next      int
+	variables map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token if it is the operator or keyword
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenOperator || t.kind == tokenIdent) && t.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("at %d: expected %q, got %s", p.peek().pos, text, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) parseTernary() (node, error) {
+	condition, err := p.parseBinary(0)
+	if err != nil || !p.accept("?") {
+		return condition, err
+	}
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &ternaryNode{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+// binaryLevels are the binary operators by precedence, from the lowest
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator := ""
+		for _, candidate := range binaryLevels[level] {
+			if p.accept(candidate) {
+				operator = candidate
+				break
+			}
+		}
+		if operator == "" {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	for _, operator := range []string{"!", "-"} {
+		if p.accept(operator) {
+			operand, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{operator: operator, operand: operand}, nil
+		}
+	}
+	return p.parseMember()
+}
+
+func (p *parser) parseMember() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.peek()
+			if t.kind != tokenIdent {
+				return nil, fmt.Errorf("at %d: expected field name, got %s", t.pos, t)
+			}
+			p.next++
+			if !p.accept("(") {
+				n = &indexNode{operand: n, index: &literalNode{value: t.text}}
+				continue
+			}
+			if _, found := methods[t.text]; !found {
+				return nil, fmt.Errorf("at %d: unknown method %s", t.pos, t.text)
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			if n, err = newCall(t, append([]node{n}, args...), methods); err != nil {
+				return nil, err
+			}
+		case p.accept("["):
+			index, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{operand: n, index: index}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	p.next++
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{value: t.value}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if p.accept("(") {
+			if _, found := functions[t.text]; !found {
+				return nil, fmt.Errorf("at %d: unknown function %s", t.pos, t.text)
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return newCall(t, args, functions)
+		}
+		if !p.variables[t.text] {
+			return nil, fmt.Errorf("at %d: unknown variable %s", t.pos, t.text)
+		}
+		return &variableNode{name: t.text}, nil
+	case tokenOperator:
+		switch t.text {
+		case "(":
+			n, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	}
+	return nil, fmt.Errorf("at %d: unexpected %s", t.pos, t)
+}
+
+// parseList parses comma separated expressions up to the closing operator
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.accept(closing) {
+		return items, nil
+	}
+	for {
+		item, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept(closing) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type node interface {
+	eval(variables map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(variables map[string]interface{}) (interface{}, error) {
+	return variables[n.name], nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(variables map[string]interface{}) (interface{}, error) {
+	list := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(variables)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+type indexNode struct {
+	operand node
+	index   node
+}
+
+func (n *indexNode) eval(variables map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	switch operand := operand.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be a string, got %s", typeName(index))
+		}
+		return operand[key], nil
+	case []interface{}:
+		i, ok := index.(float64)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("list index must be an integer, got %v", index)
+		}
+		if i < 0 || int(i) >= len(operand) {
+			return nil, fmt.Errorf("list index %v out of range", i)
+		}
+		return operand[int(i)], nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(operand))
+}
+
+type unaryNode struct {
+	operator string
+	operand  node
+}
+
+func (n *unaryNode) eval(variables map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	if n.operator == "!" {
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! expects bool, got %s", typeName(operand))
+		}
+		return !b, nil
+	}
+	f, ok := operand.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operator - expects number, got %s", typeName(operand))
+	}
+	return -f, nil
+}
+
+type binaryNode struct {
+	operator    string
+	left, right node
+}
+
+func (n *binaryNode) eval(variables map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators short-circuit
+	if n.operator == "&&" || n.operator == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects bool, got %s", n.operator, typeName(left))
+		}
+		if l == (n.operator == "||") {
+			return l, nil
+		}
+		right, err := n.right.eval(variables)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects bool, got %s", n.operator, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	switch n.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		switch container := right.(type) {
+		case []interface{}:
+			for _, item := range container {
+				if equal(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := left.(string)
+			if !ok {
+				return false, nil
+			}
+			_, found := container[key]
+			return found, nil
+		}
+		return nil, fmt.Errorf("operator in expects list or map, got %s", typeName(right))
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s: %v", n.operator, err)
+		}
+		switch n.operator {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+
+	if l, ok := left.(string); ok && n.operator == "+" {
+		if r, ok := right.(string); ok {
+			return l + r, nil
+		}
+	}
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s expects numbers, got %s and %s", n.operator, typeName(left), typeName(right))
+	}
+	switch n.operator {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+	if r == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if n.operator == "/" {
+		return l / r, nil
+	}
+	return math.Mod(l, r), nil
+}
+
+type ternaryNode struct {
+	condition, then, otherwise node
+}
+
+func (n *ternaryNode) eval(variables map[string]interface{}) (interface{}, error) {
+	condition, err := n.condition.eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := condition.(bool)
+	if !ok {
+		return nil, fmt.Errorf("condition of ?: must be bool, got %s", typeName(condition))
+	}
+	if c {
+		return n.then.eval(variables)
+	}
+	return n.otherwise.eval(variables)
+}
+
+type function struct {
+	numArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+type callNode struct {
+	name string
+	args []node
+	call func(args []interface{}) (interface{}, error)
+}
+
+// newCall returns the call of the function named by the token, checking the # arguments
+func newCall(t token, args []node, functions map[string]function) (node, error) {
+	f := functions[t.text]
+	if len(args) != f.numArgs {
+		return nil, fmt.Errorf("at %d: %s expects %d arguments, got %d", t.pos, t.text, f.numArgs, len(args))
+	}
+	return &callNode{name: t.text, args: args, call: f.call}, nil
+}
+
+func (n *callNode) eval(variables map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(variables)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	value, err := n.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", n.name, err)
+	}
+	return value, nil
+}
+
+var functions = map[string]function{
+	"size": {numArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		switch arg := args[0].(type) {
+		case string:
+			return float64(len(arg)), nil
+		case []interface{}:
+			return float64(len(arg)), nil
+		case map[string]interface{}:
+			return float64(len(arg)), nil
+		}
+		return nil, fmt.Errorf("expects string, list or map, got %s", typeName(args[0]))
+	}},
+	"min": {numArgs: 2, call: numbers(math.Min)},
+	"max": {numArgs: 2, call: numbers(math.Max)},
+}
+
+// methods are called on their first argument, e.g. s.startsWith(t)
+var methods = map[string]function{
+	"startsWith": {numArgs: 2, call: strs(strings.HasPrefix)},
+	"endsWith":   {numArgs: 2, call: strs(strings.HasSuffix)},
+	"contains":   {numArgs: 2, call: strs(strings.Contains)},
+}
+
+func numbers(f func(float64, float64) float64) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		l, lok := args[0].(float64)
+		r, rok := args[1].(float64)
+		if !lok || !rok {
+			return nil, fmt.Errorf("expects numbers, got %s and %s", typeName(args[0]), typeName(args[1]))
+		}
+		return f(l, r), nil
+	}
+}
+
+func strs(f func(string, string) bool) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		l, lok := args[0].(string)
+		r, rok := args[1].(string)
+		if !lok || !rok {
+			return nil, fmt.Errorf("expects strings, got %s and %s", typeName(args[0]), typeName(args[1]))
+		}
+		return f(l, r), nil
+	}
+}
+
+// equal returns whether the values are of the same type and equal
+func equal(l, r interface{}) bool {
+	return reflect.DeepEqual(l, r)
+}
+
+// compare orders numbers and strings, returning a negative number if l is less than r, 0 if they are
+// equal and a positive number otherwise
+func compare(l, r interface{}) (int, error) {
+	switch l := l.(type) {
+	case float64:
+		if r, ok := r.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := r.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", typeName(l), typeName(r))
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
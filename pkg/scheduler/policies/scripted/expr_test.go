@@ -0,0 +1,113 @@
+package scripted
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpression_Evaluate(t *testing.T) {
+	variables := map[string]interface{}{
+		"job": map[string]interface{}{
+			"namespace":   "prod",
+			"numReplicas": 4.0,
+			"labels":      map[string]interface{}{"team": "ml"},
+			"customFields": map[string]interface{}{
+				"tags": []interface{}{"gpu", "large"},
+			},
+		},
+		"now": 1000.0,
+	}
+
+	tests := []struct {
+		source   string
+		expected interface{}
+	}{
+		{source: `job.namespace == "prod" ? 0 : 1`, expected: 0.0},
+		{source: `job.namespace == 'dev' ? 0 : 1`, expected: 1.0},
+		{source: `1 + 2 * 3 - 4 / 2`, expected: 5.0},
+		{source: `(1 + 2) * 3 % 4`, expected: 1.0},
+		{source: `-job.numReplicas`, expected: -4.0},
+		{source: `job.numReplicas >= 4 && !(job.labels["team"] != "ml")`, expected: true},
+		{source: `job.labels.missing == null`, expected: true},
+		{source: `job.missing.deeper == null`, expected: true},
+		{source: `job.namespace in ["prod", "staging"]`, expected: true},
+		{source: `"team" in job.labels`, expected: true},
+		{source: `"gpu" in job.customFields.tags`, expected: true},
+		{source: `job.customFields.tags[1]`, expected: "large"},
+		{source: `size(job.customFields.tags) + size("abc")`, expected: 5.0},
+		{source: `min(job.numReplicas, 2) + max(job.numReplicas, 2)`, expected: 6.0},
+		{source: `job.namespace.startsWith("pr") && job.namespace.endsWith("od") && job.namespace.contains("ro")`, expected: true},
+		{source: `"a" + "b" < "b"`, expected: true},
+		{source: `1 == "1"`, expected: false},
+		{source: `false && job.missing > 1`, expected: false},
+		{source: `true || job.missing > 1`, expected: true},
+		{source: `job.numReplicas > 2 ? job.numReplicas > 3 ? "xl" : "l" : "s"`, expected: "xl"},
+		{source: `now - 400`, expected: 600.0},
+	}
+
+	for _, test := range tests {
+		e, err := Compile(test.source, []string{"job", "now"})
+		if err != nil {
+			t.Errorf("%s: failed to compile: %v", test.source, err)
+			continue
+		}
+		value, err := e.Evaluate(variables)
+		if err != nil {
+			t.Errorf("%s: failed to evaluate: %v", test.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.source, test.expected, value)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	sources := []string{
+		``,
+		`1 +`,
+		`(1`,
+		`job.`,
+		`"unterminated`,
+		`1 ? 2`,
+		`unknown.field`,
+		`unknown(1)`,
+		`job.name.unknown("a")`,
+		`size(1, 2)`,
+		`1 2`,
+		`1 # 2`,
+	}
+
+	for _, source := range sources {
+		if _, err := Compile(source, []string{"job"}); err == nil {
+			t.Errorf("%s: expected error", source)
+		}
+	}
+}
+
+func TestExpression_EvaluateErrors(t *testing.T) {
+	sources := []string{
+		`job.name + 1`,
+		`job.name < 1`,
+		`1 / 0`,
+		`!job.name`,
+		`job.name ? 1 : 2`,
+		`job.name && true`,
+		`1 in job.name`,
+		`job.name[0]`,
+		`[1][1]`,
+		`size(1)`,
+	}
+
+	variables := map[string]interface{}{"job": map[string]interface{}{"name": "j1"}}
+	for _, source := range sources {
+		e, err := Compile(source, []string{"job"})
+		if err != nil {
+			t.Errorf("%s: failed to compile: %v", source, err)
+			continue
+		}
+		if value, err := e.Evaluate(variables); err == nil {
+			t.Errorf("%s: expected error, got %v", source, value)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package scripted
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// orderKey is the expression waiting jobs are ordered by, lowest first. It results in a number or a
+	// string for all jobs.
+	orderKey = "order"
+	// filterKey is the expression waiting jobs must satisfy to be started
+	filterKey = "filter"
+	// maxReplicasKey is the expression capping the # replicas of each job
+	maxReplicasKey = "maxReplicas"
+)
+
+// variables are the variables of the expressions: job, the fields of the job, and now, the time of the
+// session in seconds since the epoch
+var variables = []string{"job", "now"}
+
+type JobCustomFields = session.RoleCustomFields
+
+// Policy starts waiting jobs in an order, subject to a filter and a cap on their replicas, all given as
+// expressions in the scheduler configuration, e.g.
+//
+//	order: 'job.namespace == "prod" ? 0 : 1'
+//	filter: 'job.labels.team != null'
+//	maxReplicas: 'job.customFields.large == true ? 8 : 4'
+//
+// The fields of job are name, namespace, uid, type, queue, priority, preemptible, numMasters,
+// numReplicas, minMasters, maxMasters, minReplicas, maxReplicas, creationTimestamp in seconds since the
+// epoch, completedIterations, running, labels, annotations and customFields, the custom fields annotation
+// parsed as YAML.
+//
+// Running jobs keep their resources, down to the cap on their replicas unless they cannot be preempted.
+// Waiting jobs that satisfy the filter are started in order of priority, then in order, with the masters
+// and replicas they ask for up to the cap. Jobs that do not fit preempt running jobs of lower priority if
+// that makes room, and are skipped otherwise. Jobs of the same priority and order are started first come
+// first served. Jobs for which an expression cannot be evaluated are not started.
+type Policy struct {
+	order       *Expression
+	filter      *Expression
+	maxReplicas *Expression
+}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	scripted := &Policy{}
+	for _, expression := range []struct {
+		key string
+		ptr **Expression
+	}{
+		{key: orderKey, ptr: &scripted.order},
+		{key: filterKey, ptr: &scripted.filter},
+		{key: maxReplicasKey, ptr: &scripted.maxReplicas},
+	} {
+		source, found := arguments[expression.key]
+		if !found || strings.TrimSpace(source) == "" {
+			continue
+		}
+		compiled, err := Compile(source, variables)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", expression.key, err)
+		}
+		*expression.ptr = compiled
+	}
+	return scripted, nil
+}
+
+func (scripted *Policy) Name() string {
+	return "scripted"
+}
+
+func (scripted *Policy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*JobCustomFields)(nil))
+}
+
+func (scripted *Policy) Initialize() {}
+
+// candidate is a waiting job that satisfies the filter, with its order and cap
+type candidate struct {
+	job         *info.JobInfo
+	order       interface{}
+	maxReplicas int32
+}
+
+func (scripted *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin scripted")
+	defer klog.V(3).Infof("End scripted")
+
+	now := float64(ssn.Now().UnixNano()) / 1e9
+
+	// Running jobs keep their resources, down to their caps
+	pool := session.NewNodePool(ssn)
+	var running []*info.JobInfo
+	var candidates []candidate
+	for _, job := range ssn.Jobs {
+		vars := map[string]interface{}{
+			"job": jobVariable(job),
+			"now": now,
+		}
+		maxReplicas, err := scripted.cap(vars)
+		if err != nil {
+			klog.Errorf("Cannot cap replicas of job <%s/%s>: %v", job.Namespace, job.Name, err)
+		}
+
+		if job.NumMasters+job.NumReplicas > 0 {
+			if err == nil && job.Preemptible && job.NumReplicas > maxReplicas && maxReplicas >= job.MinReplicas {
+				job.NumReplicas = maxReplicas
+				ssn.Explainf(job, session.ReasonScheduled, "replicas capped at %d", maxReplicas)
+			}
+			if !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+				klog.Warningf("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+			}
+			running = append(running, job)
+			continue
+		}
+
+		if err != nil {
+			ssn.Explainf(job, session.ReasonNotScheduled, "%v", err)
+			continue
+		}
+		if scripted.filter != nil {
+			eligible, err := scripted.filter.EvaluateBool(vars)
+			if err != nil {
+				klog.Errorf("Cannot filter job <%s/%s>: %v", job.Namespace, job.Name, err)
+				ssn.Explainf(job, session.ReasonNotScheduled, "%v", err)
+				continue
+			}
+			if !eligible {
+				ssn.Explainf(job, session.ReasonNotScheduled, "excluded by filter %s", scripted.filter)
+				continue
+			}
+		}
+		var order interface{} = 0.0
+		if scripted.order != nil {
+			if order, err = scripted.order.Evaluate(vars); err != nil {
+				klog.Errorf("Cannot order job <%s/%s>: %v", job.Namespace, job.Name, err)
+				ssn.Explainf(job, session.ReasonNotScheduled, "%v", err)
+				continue
+			}
+		}
+		candidates = append(candidates, candidate{job: job, order: order, maxReplicas: maxReplicas})
+	}
+
+	if err := sortCandidates(candidates); err != nil {
+		klog.Errorf("Cannot order jobs by %s: %v", scripted.order, err)
+		for _, c := range candidates {
+			ssn.Explainf(c.job, session.ReasonNotScheduled, "cannot order jobs: %v", err)
+		}
+		return
+	}
+
+	// Schedule
+	for i, c := range candidates {
+		position := i + 1
+		job := c.job
+		numMasters, numReplicas := session.RequestedRoles(job)
+		if numReplicas > c.maxReplicas {
+			if c.maxReplicas < job.MinReplicas {
+				ssn.Explainf(job, session.ReasonNotScheduled, "queue position %d, replicas capped at %d below the minimum of %d",
+					position, c.maxReplicas, job.MinReplicas)
+				continue
+			}
+			numReplicas = c.maxReplicas
+		}
+		if !pool.AllocateJob(job, numMasters, numReplicas) {
+			victims := pool.Preempt(job, numMasters, numReplicas, running)
+			if victims == nil {
+				ssn.Explainf(job, session.ReasonInsufficientResources, "queue position %d, %s",
+					position, pool.Shortage(job, numMasters, numReplicas))
+				continue
+			}
+			running = preempt(ssn, running, job, victims)
+			job.NumMasters = numMasters
+			job.NumReplicas = numReplicas
+			ssn.Explainf(job, session.ReasonScheduled, "queue position %d, preempted %d jobs of lower priority",
+				position, len(victims))
+			continue
+		}
+		job.NumMasters = numMasters
+		job.NumReplicas = numReplicas
+		ssn.Explainf(job, session.ReasonScheduled, "queue position %d, allocated %d masters and %d replicas",
+			position, numMasters, numReplicas)
+	}
+}
+
+func (scripted *Policy) UnInitialize() {}
+
+// cap returns the # replicas the job is capped at, math.MaxInt32 if there is no cap
+func (scripted *Policy) cap(vars map[string]interface{}) (int32, error) {
+	if scripted.maxReplicas == nil {
+		return math.MaxInt32, nil
+	}
+	maxReplicas, err := scripted.maxReplicas.EvaluateNumber(vars)
+	if err != nil {
+		return 0, err
+	}
+	if maxReplicas < 0 {
+		return 0, nil
+	}
+	if maxReplicas >= math.MaxInt32 {
+		return math.MaxInt32, nil
+	}
+	return int32(maxReplicas), nil
+}
+
+// sortCandidates sorts the candidates by priority, higher first, then by order, then first come first
+// served. All orders must be numbers, or all of them strings.
+func sortCandidates(candidates []candidate) error {
+	for _, c := range candidates {
+		if _, err := compare(candidates[0].order, c.order); err != nil {
+			return err
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		l, r := candidates[i], candidates[j]
+		if l.job.Priority != r.job.Priority {
+			return l.job.Priority > r.job.Priority
+		}
+		if c, _ := compare(l.order, r.order); c != 0 {
+			return c < 0
+		}
+		return session.ArrivedBefore(l.job, r.job)
+	})
+	return nil
+}
+
+// preempt stops the victims for the preemptor and returns the running jobs left
+func preempt(ssn *session.Session, running []*info.JobInfo, preemptor *info.JobInfo, victims []*info.JobInfo) []*info.JobInfo {
+	for _, victim := range victims {
+		klog.V(4).Infof("Preempting job <%s/%s> of priority %d", victim.Namespace, victim.Name, victim.Priority)
+		victim.NumMasters = 0
+		victim.NumReplicas = 0
+		ssn.Explainf(victim, session.ReasonPreempted, "preempted by job %s/%s of priority %d",
+			preemptor.Namespace, preemptor.Name, preemptor.Priority)
+	}
+	remaining := running[:0]
+	for _, job := range running {
+		if job.NumMasters+job.NumReplicas > 0 {
+			remaining = append(remaining, job)
+		}
+	}
+	return remaining
+}
+
+// jobVariable returns the value of the job variable of the expressions
+func jobVariable(job *info.JobInfo) map[string]interface{} {
+	annotations := job.Job.GetAnnotations()
+	var customFields interface{}
+	if err := yaml.Unmarshal([]byte(annotations["pinta.qed.usc.edu/custom-fields"]), &customFields); err != nil {
+		klog.Errorf("Cannot parse custom fields for job %v: %v", job.Name, err)
+	}
+	running := job.NumMasters+job.NumReplicas > 0
+	return map[string]interface{}{
+		"name":                job.Name,
+		"namespace":           job.Namespace,
+		"uid":                 string(job.UID),
+		"type":                string(job.Type),
+		"queue":               job.Queue,
+		"priority":            float64(job.Priority),
+		"preemptible":         job.Preemptible,
+		"numMasters":          float64(job.NumMasters),
+		"numReplicas":         float64(job.NumReplicas),
+		"minMasters":          float64(job.MinMasters),
+		"maxMasters":          float64(job.MaxMasters),
+		"minReplicas":         float64(job.MinReplicas),
+		"maxReplicas":         float64(job.MaxReplicas),
+		"creationTimestamp":   float64(job.CreationTimestamp.UnixNano()) / 1e9,
+		"completedIterations": float64(job.CompletedIterations()),
+		"running":             running,
+		"labels":              stringMap(job.Job.GetLabels()),
+		"annotations":         stringMap(annotations),
+		"customFields":        value(customFields),
+	}
+}
+
+func stringMap(m map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		values[k] = v
+	}
+	return values
+}
+
+// value converts a value parsed from YAML to a value of the expressions
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = value(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = value(item)
+		}
+		return list
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64, string, bool, nil:
+		return v
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,258 @@
+package scripted
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments session.Arguments
+		valid     bool
+	}{
+		{name: "no expressions", arguments: session.Arguments{}, valid: true},
+		{
+			name: "valid expressions",
+			arguments: session.Arguments{
+				"order":       `job.namespace == "prod" ? 0 : 1`,
+				"filter":      `job.labels.team != null`,
+				"maxReplicas": `min(job.customFields.maxReplicas, 4)`,
+			},
+			valid: true,
+		},
+		{name: "syntax error", arguments: session.Arguments{"order": `job.namespace ==`}},
+		{name: "unknown variable", arguments: session.Arguments{"filter": `pod.name == "p"`}},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.arguments)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestPolicy_Execute(t *testing.T) {
+	now := time.Unix(1000, 0)
+	team := map[string]string{"team": "ml"}
+
+	tests := []struct {
+		name      string
+		arguments session.Arguments
+		numNodes  int
+		jobs      []*info.JobInfo
+		expected  map[info.JobID]int32
+		reasons   map[info.JobID]string
+	}{
+		{
+			name:      "first come first served",
+			arguments: session.Arguments{},
+			numNodes:  3,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("prod"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 2, "prod/j2": 0, "dev/j3": 1},
+			reasons:  map[info.JobID]string{"prod/j2": session.ReasonInsufficientResources},
+		},
+		{
+			name:      "order",
+			arguments: session.Arguments{"order": `job.namespace == "prod" ? 0 : 1`},
+			numNodes:  3,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("prod"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 0, "prod/j2": 2, "dev/j3": 1},
+			reasons:  map[info.JobID]string{"dev/j1": session.ReasonInsufficientResources, "prod/j2": session.ReasonScheduled},
+		},
+		{
+			name:      "order by string",
+			arguments: session.Arguments{"order": `job.name`},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 2, "dev/j2": 0},
+		},
+		{
+			name:      "filter",
+			arguments: session.Arguments{"filter": `job.labels.team != null`},
+			numNodes:  3,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithLabels(team), sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 0, "dev/j2": 1},
+			reasons:  map[info.JobID]string{"dev/j1": session.ReasonNotScheduled},
+		},
+		{
+			name:      "max replicas",
+			arguments: session.Arguments{"maxReplicas": `job.customFields.large == true ? 2 : 1`},
+			numNodes:  4,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 3")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 3, large: true}")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 1, "dev/j2": 2},
+		},
+		{
+			name:      "running jobs are capped",
+			arguments: session.Arguments{"maxReplicas": `2`},
+			numNodes:  4,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 4"), sessiontest.Running(4)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 2, "dev/j2": 2},
+		},
+		{
+			name:      "non-preemptible running jobs are not capped",
+			arguments: session.Arguments{"maxReplicas": `2`},
+			numNodes:  4,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 4"), sessiontest.WithPriority(0, false),
+					sessiontest.Running(4)),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 4},
+		},
+		{
+			name:      "priority before order",
+			arguments: session.Arguments{"order": `job.namespace == "prod" ? 0 : 1`},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2"), sessiontest.WithPriority(1, true)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("prod"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 2")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 2, "prod/j2": 0},
+			reasons:  map[info.JobID]string{"prod/j2": session.ReasonInsufficientResources},
+		},
+		{
+			name:      "preemption",
+			arguments: session.Arguments{},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-3*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1"), sessiontest.WithPriority(0, true),
+					sessiontest.Running(1)),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1"), sessiontest.WithPriority(0, false),
+					sessiontest.Running(1)),
+				sessiontest.BuildJob("j3", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1"), sessiontest.WithPriority(1, true)),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 0, "dev/j2": 1, "dev/j3": 1},
+			reasons:  map[info.JobID]string{"dev/j1": session.ReasonPreempted, "dev/j3": session.ReasonScheduled},
+		},
+		{
+			name:      "cap below minimum",
+			arguments: session.Arguments{"maxReplicas": `0`},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 0},
+			reasons:  map[info.JobID]string{"dev/j1": session.ReasonNotScheduled},
+		},
+		{
+			name:      "evaluation error",
+			arguments: session.Arguments{"filter": `job.customFields.team.startsWith("m")`},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("{numReplicas: 1, team: ml}")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 0, "dev/j2": 1},
+			reasons:  map[info.JobID]string{"dev/j1": session.ReasonNotScheduled},
+		},
+		{
+			name:      "mixed order types",
+			arguments: session.Arguments{"order": `job.namespace == "prod" ? 0 : "last"`},
+			numNodes:  2,
+			jobs: []*info.JobInfo{
+				sessiontest.BuildJob("j1", customFieldsType,
+					sessiontest.InNamespace("dev"), sessiontest.CreatedAt(now.Add(-2*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+				sessiontest.BuildJob("j2", customFieldsType,
+					sessiontest.InNamespace("prod"), sessiontest.CreatedAt(now.Add(-1*time.Second)),
+					sessiontest.WithCustomFields("numReplicas: 1")),
+			},
+			expected: map[info.JobID]int32{"dev/j1": 0, "prod/j2": 0},
+			reasons:  map[info.JobID]string{"dev/j1": session.ReasonNotScheduled, "prod/j2": session.ReasonNotScheduled},
+		},
+	}
+
+	for _, test := range tests {
+		policy, err := New(test.arguments)
+		if err != nil {
+			t.Fatalf("%s: failed to build policy: %v", test.name, err)
+		}
+		snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nil), test.jobs...)
+		ssn := session.OpenSessionWithSnapshot(snapshot, nil, now)
+		policy.Execute(ssn)
+
+		for id, numReplicas := range test.expected {
+			if ssn.Jobs[id].NumReplicas != numReplicas {
+				t.Errorf("%s: expected job %s to have %d replicas, got %d", test.name, id, numReplicas, ssn.Jobs[id].NumReplicas)
+			}
+		}
+		for id, reason := range test.reasons {
+			if explanation := ssn.Explanation(ssn.Jobs[id]); explanation.Reason != reason {
+				t.Errorf("%s: expected job %s to be explained by %s, got %+v", test.name, id, reason, explanation)
+			}
+		}
+	}
+}
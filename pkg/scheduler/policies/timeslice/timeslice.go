@@ -0,0 +1,198 @@
+package timeslice
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"k8s.io/klog"
+	"reflect"
+	"sort"
+)
+
+const (
+	// quantumKey is the # seconds a job keeps its resources once it gets them, before it may be
+	// preempted in favor of waiting jobs
+	quantumKey = "quantum"
+)
+
+type JobCustomFields = session.RoleCustomFields
+
+// JobState is kept for each job between sessions
+type JobState struct {
+	Seen bool `yaml:"seen"`
+
+	// The time since when the job has been waiting, or is running without interruption
+	QueuedSince  float64 `yaml:"queuedSince"`
+	RunningSince float64 `yaml:"runningSince"`
+
+	// SliceStart is the start of the current time slice of a running job
+	SliceStart float64 `yaml:"sliceStart"`
+}
+
+// Policy is a time-slicing policy in the style of Gandiva. When the jobs ask for more than the cluster
+// has, they take turns running for time slices of one quantum. Running jobs keep their resources until
+// their slice ends. Then waiting jobs go first, in the order they started waiting, followed by the jobs
+// whose slices ended, the longest running last.
+//
+// The first waiting job that does not fit blocks the jobs behind it, so that it gets the resources of
+// the next slices that end. Every job thus runs for at least one quantum per rotation through the jobs.
+// Jobs whose slices ended keep running past them while the blocked job does not fit without them anyway.
+//
+// Jobs only take turns with jobs of the same priority. Jobs of higher priority go first and preempt
+// running jobs of lower priority before the end of their slices, while jobs that cannot be preempted
+// keep their resources past the end of their slices.
+type Policy struct {
+	quantum float64
+}
+
+func New(arguments session.Arguments) (session.Policy, error) {
+	timeslice := &Policy{
+		quantum: 600,
+	}
+	if err := arguments.GetFloat64(&timeslice.quantum, quantumKey); err != nil {
+		return nil, err
+	}
+	if timeslice.quantum <= 0 {
+		return nil, fmt.Errorf("argument %s must be positive", quantumKey)
+	}
+	return timeslice, nil
+}
+
+func (timeslice *Policy) Name() string {
+	return "timeslice"
+}
+
+func (timeslice *Policy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*JobCustomFields)(nil))
+}
+
+func (timeslice *Policy) JobStateType() reflect.Type {
+	return reflect.TypeOf((*JobState)(nil))
+}
+
+func (timeslice *Policy) Initialize() {}
+
+func (timeslice *Policy) Execute(ssn *session.Session) {
+	klog.V(3).Infof("Begin time slicing")
+	defer klog.V(3).Infof("End time slicing")
+
+	now := float64(ssn.Now().UnixNano()) / 1e9
+
+	// Running jobs keep their resources until the end of their slices, or for good if they cannot be
+	// preempted
+	pool := session.NewNodePool(ssn)
+	var waiting, expired, kept []*info.JobInfo
+	for _, job := range ssn.Jobs {
+		state := job.State.(*JobState)
+		running := job.NumMasters+job.NumReplicas > 0
+		if !state.Seen {
+			state.Seen = true
+			state.QueuedSince = float64(job.CreationTimestamp.UnixNano()) / 1e9
+			if running {
+				state.RunningSince = now
+				state.SliceStart = now
+			}
+		}
+
+		if !running {
+			waiting = append(waiting, job)
+		} else if now-state.SliceStart >= timeslice.quantum && job.Preemptible {
+			expired = append(expired, job)
+		} else {
+			if !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+				klog.Warningf("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+			}
+			kept = append(kept, job)
+			if job.Preemptible {
+				ssn.Explainf(job, session.ReasonScheduled, "slice ends in %.0fs", timeslice.quantum-(now-state.SliceStart))
+			}
+		}
+	}
+
+	sort.Slice(waiting, func(i, j int) bool {
+		l, r := waiting[i].State.(*JobState), waiting[j].State.(*JobState)
+		if l.QueuedSince != r.QueuedSince {
+			return l.QueuedSince < r.QueuedSince
+		}
+		return session.ArrivedBefore(waiting[i], waiting[j])
+	})
+	sort.Slice(expired, func(i, j int) bool {
+		l, r := expired[i].State.(*JobState), expired[j].State.(*JobState)
+		if l.RunningSince != r.RunningSince {
+			return l.RunningSince > r.RunningSince
+		}
+		return session.ArrivedBefore(expired[i], expired[j])
+	})
+
+	// Jobs of higher priority go first, and take turns with the jobs of the same priority
+	queue := append(waiting, expired...)
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].Priority > queue[j].Priority
+	})
+
+	// Hand out the resources left in turn, preempting jobs of lower priority if needed
+	var blocked *info.JobInfo
+	var blockedMasters, blockedReplicas int32
+	for _, job := range queue {
+		state := job.State.(*JobState)
+		running := job.NumMasters+job.NumReplicas > 0
+		numMasters, numReplicas := job.NumMasters, job.NumReplicas
+		if !running {
+			numMasters, numReplicas = session.RequestedRoles(job)
+		}
+
+		allocated := blocked == nil && pool.AllocateJob(job, numMasters, numReplicas)
+		if !allocated && blocked == nil {
+			if victims := pool.Preempt(job, numMasters, numReplicas, kept); victims != nil {
+				for _, victim := range victims {
+					klog.V(4).Infof("Job <%s/%s> is preempted by job <%s/%s> of higher priority",
+						victim.Namespace, victim.Name, job.Namespace, job.Name)
+					victim.State.(*JobState).QueuedSince = now
+					victim.NumMasters = 0
+					victim.NumReplicas = 0
+					ssn.Explainf(victim, session.ReasonPreempted, "preempted by job %s/%s of priority %d",
+						job.Namespace, job.Name, job.Priority)
+				}
+				kept = session.StillRunning(kept)
+				allocated = true
+			}
+		}
+		if allocated {
+			if !running {
+				state.RunningSince = now
+			}
+			state.SliceStart = now
+			job.NumMasters = numMasters
+			job.NumReplicas = numReplicas
+			kept = append(kept, job)
+			ssn.Explainf(job, session.ReasonScheduled, "slice of %.0fs started", timeslice.quantum)
+			continue
+		}
+
+		if running && blocked != nil && !pool.Clone().AllocateJob(blocked, blockedMasters, blockedReplicas) &&
+			pool.AllocateJob(job, numMasters, numReplicas) {
+			// Stopping the job would leave its resources idle, so it runs on without a new slice until the
+			// blocked job fits
+			kept = append(kept, job)
+			ssn.Explainf(job, session.ReasonScheduled, "slice ended, running until job %s/%s fits",
+				blocked.Namespace, blocked.Name)
+		} else if running {
+			klog.V(4).Infof("Job <%s/%s> is preempted at the end of its slice", job.Namespace, job.Name)
+			state.QueuedSince = now
+			job.NumMasters = 0
+			job.NumReplicas = 0
+			ssn.Explainf(job, session.ReasonQueued, "slice of %.0fs ended, waiting for the next turn", timeslice.quantum)
+		} else if blocked != nil {
+			ssn.Explainf(job, session.ReasonQueued, "waiting behind job %s/%s", blocked.Namespace, blocked.Name)
+		} else {
+			ssn.Explainf(job, session.ReasonInsufficientResources, "%s", pool.Shortage(job, numMasters, numReplicas))
+			if session.NewNodePool(ssn).AllocateJob(job, numMasters, numReplicas) {
+				// Jobs that could never fit do not block the others
+				blocked = job
+				blockedMasters, blockedReplicas = numMasters, numReplicas
+			}
+		}
+	}
+}
+
+func (timeslice *Policy) UnInitialize() {}
@@ -0,0 +1,147 @@
+package timeslice
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session/sessiontest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var customFieldsType = reflect.TypeOf((*JobCustomFields)(nil))
+
+func TestPolicy_Execute(t *testing.T) {
+	start := time.Unix(1000, 0)
+	created := map[info.JobID]time.Time{
+		"j1": start.Add(-3 * time.Second),
+		"j2": start.Add(-2 * time.Second),
+		"j3": start.Add(-1 * time.Second),
+	}
+
+	tests := []struct {
+		name         string
+		numNodes     int
+		customFields map[info.JobID]string
+		priorities   map[info.JobID]int32
+		// nonPreemptible are the jobs that cannot be preempted
+		nonPreemptible map[info.JobID]bool
+		// A session is run at each time after start, expecting the # replicas of each job in steps
+		after []time.Duration
+		steps []map[info.JobID]int32
+	}{
+		{
+			name:         "jobs take turns",
+			numNodes:     2,
+			customFields: map[info.JobID]string{"j1": "numReplicas: 1", "j2": "numReplicas: 1", "j3": "numReplicas: 1"},
+			after:        []time.Duration{0, 50 * time.Second, 100 * time.Second, 200 * time.Second, 300 * time.Second},
+			steps: []map[info.JobID]int32{
+				{"j1": 1, "j2": 1, "j3": 0},
+				{"j1": 1, "j2": 1, "j3": 0},
+				{"j1": 1, "j2": 0, "j3": 1},
+				{"j1": 0, "j2": 1, "j3": 1},
+				{"j1": 1, "j2": 1, "j3": 0},
+			},
+		},
+		{
+			name:         "no preemption without contention",
+			numNodes:     3,
+			customFields: map[info.JobID]string{"j1": "numReplicas: 1", "j2": "numReplicas: 2"},
+			after:        []time.Duration{0, 100 * time.Second, 200 * time.Second},
+			steps: []map[info.JobID]int32{
+				{"j1": 1, "j2": 2},
+				{"j1": 1, "j2": 2},
+				{"j1": 1, "j2": 2},
+			},
+		},
+		{
+			name:         "blocked job gets the next slice",
+			numNodes:     2,
+			customFields: map[info.JobID]string{"j1": "numReplicas: 1", "j2": "numReplicas: 2", "j3": "numReplicas: 1"},
+			after:        []time.Duration{0, 50 * time.Second, 100 * time.Second},
+			steps: []map[info.JobID]int32{
+				{"j1": 1, "j2": 0, "j3": 0},
+				{"j1": 1, "j2": 0, "j3": 0},
+				{"j1": 0, "j2": 2, "j3": 0},
+			},
+		},
+		{
+			name:         "jobs of higher priority do not take turns with the others",
+			numNodes:     2,
+			customFields: map[info.JobID]string{"j1": "numReplicas: 1", "j2": "numReplicas: 1", "j3": "numReplicas: 1"},
+			priorities:   map[info.JobID]int32{"j3": 1},
+			after:        []time.Duration{0, 50 * time.Second, 100 * time.Second},
+			steps: []map[info.JobID]int32{
+				{"j1": 1, "j2": 0, "j3": 1},
+				{"j1": 1, "j2": 0, "j3": 1},
+				{"j1": 0, "j2": 1, "j3": 1},
+			},
+		},
+		{
+			name:           "slices that end keep running while the blocked job does not fit",
+			numNodes:       3,
+			customFields:   map[info.JobID]string{"j1": "numReplicas: 1", "j2": "numReplicas: 1", "j3": "numReplicas: 3"},
+			nonPreemptible: map[info.JobID]bool{"j1": true},
+			after:          []time.Duration{0, 100 * time.Second, 200 * time.Second},
+			steps: []map[info.JobID]int32{
+				{"j1": 1, "j2": 1, "j3": 0},
+				{"j1": 1, "j2": 1, "j3": 0},
+				{"j1": 1, "j2": 1, "j3": 0},
+			},
+		},
+		{
+			name:         "jobs that never fit do not block",
+			numNodes:     2,
+			customFields: map[info.JobID]string{"j1": "numReplicas: 3", "j2": "numReplicas: 1"},
+			after:        []time.Duration{0},
+			steps: []map[info.JobID]int32{
+				{"j1": 0, "j2": 1},
+			},
+		},
+	}
+
+	policy, err := New(session.Arguments{"quantum": "100"})
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	for _, test := range tests {
+		store := cache.NewMemoryJobStateStore()
+		allocation := make(map[info.JobID]int32)
+		for i, expected := range test.steps {
+			var jobs []*info.JobInfo
+			for id, customFieldsStr := range test.customFields {
+				job := sessiontest.BuildJob(string(id), customFieldsType,
+					sessiontest.CreatedAt(created[id]), sessiontest.Running(allocation[id]),
+					sessiontest.WithCustomFields(customFieldsStr))
+				job.Priority = test.priorities[id]
+				job.Preemptible = !test.nonPreemptible[id]
+				jobs = append(jobs, job)
+			}
+			snapshot := sessiontest.BuildSnapshot(sessiontest.BuildNodes("", test.numNodes, nil), jobs...)
+			ssn := session.OpenSessionWithSnapshot(snapshot, store, start.Add(test.after[i]))
+			session.ExecutePolicies(ssn, []session.Policy{policy})
+
+			for id, job := range ssn.Jobs {
+				allocation[id] = job.NumReplicas
+				if job.NumReplicas != expected[id] {
+					t.Errorf("%s: job %v expected %d replicas after %v, got %d",
+						test.name, id, expected[id], test.after[i], job.NumReplicas)
+				}
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	invalidArguments := []session.Arguments{
+		{"quantum": "0"},
+		{"quantum": "soon"},
+	}
+	for _, arguments := range invalidArguments {
+		if _, err := New(arguments); err == nil {
+			t.Errorf("Expected error building policy with arguments %v", arguments)
+		}
+	}
+}
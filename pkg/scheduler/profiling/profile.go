@@ -0,0 +1,212 @@
+package profiling
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"math"
+	"sort"
+)
+
+// Model is a scaling model of the throughput of a job over its # replicas
+type Model string
+
+const (
+	// Amdahl models the throughput at n replicas as X(n) = 1 / (a + b/n), where a is the time per
+	// iteration that cannot be parallelized
+	Amdahl Model = "amdahl"
+	// PowerLaw models the throughput at n replicas as X(n) = a * n^b with 0 <= b <= 1
+	PowerLaw Model = "powerLaw"
+)
+
+// ParseModel returns the model with the given name
+func ParseModel(name string) (Model, error) {
+	switch Model(name) {
+	case Amdahl, PowerLaw:
+		return Model(name), nil
+	}
+	return "", fmt.Errorf("unknown throughput model %s, expected %s or %s", name, Amdahl, PowerLaw)
+}
+
+// Measurement is the # iterations a job completed in the time it ran with some # replicas
+type Measurement struct {
+	Iterations float64 `yaml:"iterations"`
+	Seconds    float64 `yaml:"seconds"`
+}
+
+// Profile accumulates the throughput of a job at each # replicas it ran with, measured from the
+// progress the job reports. It is meant to be kept in the state of a policy between sessions.
+type Profile struct {
+	Measurements map[int32]*Measurement `yaml:"measurements,omitempty"`
+
+	// The last progress observed, and the # replicas the job had at that time
+	LastIterations  int     `yaml:"lastIterations"`
+	LastReportTime  float64 `yaml:"lastReportTime"`
+	LastNumReplicas int32   `yaml:"lastNumReplicas"`
+}
+
+// Observe records the latest progress reported by the job. Progress made while the job kept the same
+// # replicas since the last observation is added to the measurement of that # replicas.
+func (p *Profile) Observe(job *info.JobInfo) {
+	if job.Progress == nil {
+		return
+	}
+	reportTime := float64(job.Progress.ReportTime.UnixNano()) / 1e9
+	if reportTime <= p.LastReportTime {
+		// No new report
+		return
+	}
+
+	iterations := job.Progress.CompletedIterations - p.LastIterations
+	if p.LastReportTime > 0 && job.NumReplicas > 0 && job.NumReplicas == p.LastNumReplicas && iterations >= 0 {
+		if p.Measurements == nil {
+			p.Measurements = make(map[int32]*Measurement)
+		}
+		m, found := p.Measurements[job.NumReplicas]
+		if !found {
+			m = &Measurement{}
+			p.Measurements[job.NumReplicas] = m
+		}
+		m.Iterations += float64(iterations)
+		m.Seconds += reportTime - p.LastReportTime
+	}
+
+	p.LastIterations = job.Progress.CompletedIterations
+	p.LastReportTime = reportTime
+	p.LastNumReplicas = job.NumReplicas
+}
+
+// Throughput returns the measured # iterations per second at each # replicas
+func (p *Profile) Throughput() map[int32]float64 {
+	throughput := make(map[int32]float64, len(p.Measurements))
+	for numReplicas, m := range p.Measurements {
+		if m.Seconds > 0 && m.Iterations > 0 {
+			throughput[numReplicas] = m.Iterations / m.Seconds
+		}
+	}
+	return throughput
+}
+
+// Curve returns the throughput in # iterations per second for 1 to maxReplicas replicas, where replica
+// counts that have not been measured are extrapolated with the model. It returns nil if nothing has
+// been measured yet.
+func (p *Profile) Curve(model Model, maxReplicas int) []float64 {
+	measured := p.Throughput()
+	if len(measured) == 0 || maxReplicas <= 0 {
+		return nil
+	}
+	fitted := Fit(model, measured)
+
+	curve := make([]float64, maxReplicas)
+	for i := range curve {
+		if throughput, found := measured[int32(i+1)]; found {
+			curve[i] = throughput
+		} else {
+			curve[i] = fitted(i + 1)
+		}
+	}
+	return curve
+}
+
+// Fit fits the model, Amdahl's law if empty, to the measured throughput at each # replicas. With a single
+// # replicas measured, the throughput is assumed to scale linearly.
+func Fit(model Model, measured map[int32]float64) func(n int) float64 {
+	ns := make([]float64, 0, len(measured))
+	xs := make([]float64, 0, len(measured))
+	keys := make([]int32, 0, len(measured))
+	for numReplicas := range measured {
+		keys = append(keys, numReplicas)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, numReplicas := range keys {
+		ns = append(ns, float64(numReplicas))
+		xs = append(xs, measured[numReplicas])
+	}
+
+	switch model {
+	case PowerLaw:
+		return fitPowerLaw(ns, xs)
+	default:
+		return fitAmdahl(ns, xs)
+	}
+}
+
+// fitAmdahl fits 1/X(n) = a + b/n by least squares with a, b >= 0
+func fitAmdahl(ns, xs []float64) func(n int) float64 {
+	us := make([]float64, len(ns))
+	ys := make([]float64, len(xs))
+	for i := range ns {
+		us[i] = 1 / ns[i]
+		ys[i] = 1 / xs[i]
+	}
+
+	a, b := 0.0, 0.0
+	if len(ns) > 1 {
+		a, b = leastSquares(us, ys)
+	}
+	if len(ns) == 1 || a < 0 {
+		// Perfectly parallel: fit b through the origin
+		a = 0
+		b = dot(us, ys) / dot(us, us)
+	} else if b < 0 {
+		// Not parallel at all: the throughput is constant
+		b = 0
+		a = mean(ys)
+	}
+
+	return func(n int) float64 {
+		return 1 / (a + b/float64(n))
+	}
+}
+
+// fitPowerLaw fits log X(n) = log a + b log n by least squares with 0 <= b <= 1
+func fitPowerLaw(ns, xs []float64) func(n int) float64 {
+	logNs := make([]float64, len(ns))
+	logXs := make([]float64, len(xs))
+	for i := range ns {
+		logNs[i] = math.Log(ns[i])
+		logXs[i] = math.Log(xs[i])
+	}
+
+	b := 1.0
+	if len(ns) > 1 {
+		_, b = leastSquares(logNs, logXs)
+		b = math.Max(0, math.Min(1, b))
+	}
+	residuals := make([]float64, len(ns))
+	for i := range ns {
+		residuals[i] = logXs[i] - b*logNs[i]
+	}
+	logA := mean(residuals)
+
+	return func(n int) float64 {
+		return math.Exp(logA + b*math.Log(float64(n)))
+	}
+}
+
+// leastSquares returns the intercept and slope of the line fitted to the points
+func leastSquares(xs, ys []float64) (float64, float64) {
+	meanX, meanY := mean(xs), mean(ys)
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+	slope := sxy / sxx
+	return meanY - slope*meanX, slope
+}
+
+func mean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func dot(xs, ys []float64) float64 {
+	sum := 0.0
+	for i := range xs {
+		sum += xs[i] * ys[i]
+	}
+	return sum
+}
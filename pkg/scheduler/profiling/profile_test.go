@@ -0,0 +1,111 @@
+package profiling
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func report(numReplicas int32, completedIterations int, seconds int64) *info.JobInfo {
+	return &info.JobInfo{
+		NumReplicas: numReplicas,
+		Progress: &info.JobProgress{
+			CompletedIterations: completedIterations,
+			ReportTime:          metav1.NewTime(time.Unix(1000+seconds, 0)),
+		},
+	}
+}
+
+func TestProfile_Observe(t *testing.T) {
+	p := &Profile{}
+	observations := []*info.JobInfo{
+		report(1, 0, 0),
+		report(1, 10, 10),
+		report(1, 10, 10), // No new report
+		report(1, 30, 20),
+		report(2, 50, 30), // Resized since the last report
+		report(2, 90, 40),
+		{NumReplicas: 2}, // No report at all
+	}
+	for _, job := range observations {
+		p.Observe(job)
+	}
+
+	expected := map[int32]float64{1: 1.5, 2: 4}
+	throughput := p.Throughput()
+	if len(throughput) != len(expected) {
+		t.Fatalf("Expected throughput %v, got %v", expected, throughput)
+	}
+	for numReplicas, x := range expected {
+		if math.Abs(throughput[numReplicas]-x) > 1e-9 {
+			t.Errorf("Expected throughput %v at %d replicas, got %v", x, numReplicas, throughput[numReplicas])
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	amdahl := func(n int) float64 { return 1 / (0.1 + 0.9/float64(n)) }
+	powerLaw := func(n int) float64 { return 2 * math.Pow(float64(n), 0.5) }
+
+	tests := []struct {
+		name     string
+		model    Model
+		measured map[int32]float64
+		expected func(n int) float64
+	}{
+		{
+			name:     "amdahl",
+			model:    Amdahl,
+			measured: map[int32]float64{1: amdahl(1), 2: amdahl(2), 4: amdahl(4)},
+			expected: amdahl,
+		},
+		{
+			name:     "power law",
+			model:    PowerLaw,
+			measured: map[int32]float64{1: powerLaw(1), 3: powerLaw(3)},
+			expected: powerLaw,
+		},
+		{
+			name:     "single measurement scales linearly",
+			model:    Amdahl,
+			measured: map[int32]float64{2: 4},
+			expected: func(n int) float64 { return 2 * float64(n) },
+		},
+		{
+			name:     "slowdown is not extrapolated",
+			model:    Amdahl,
+			measured: map[int32]float64{1: 4, 2: 3},
+			expected: func(n int) float64 { return 1 / ((1.0/4 + 1.0/3) / 2) },
+		},
+	}
+
+	for _, test := range tests {
+		fitted := Fit(test.model, test.measured)
+		for n := 1; n <= 8; n++ {
+			if math.Abs(fitted(n)-test.expected(n)) > 1e-6 {
+				t.Errorf("%s: expected %v at %d replicas, got %v", test.name, test.expected(n), n, fitted(n))
+			}
+		}
+	}
+}
+
+func TestProfile_Curve(t *testing.T) {
+	p := &Profile{
+		Measurements: map[int32]*Measurement{
+			2: {Iterations: 40, Seconds: 10},
+		},
+	}
+	if curve := (&Profile{}).Curve(Amdahl, 4); curve != nil {
+		t.Errorf("Expected no curve without measurements, got %v", curve)
+	}
+	expected := []float64{2, 4, 6, 8}
+	curve := p.Curve(Amdahl, 4)
+	for i := range expected {
+		if i >= len(curve) || math.Abs(curve[i]-expected[i]) > 1e-9 {
+			t.Fatalf("Expected curve %v, got %v", expected, curve)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package profiling
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+// JobCustomFields are the custom fields of jobs whose remaining time policies estimate from their
+// throughput
+type JobCustomFields struct {
+	BatchSize  int       `yaml:"batchSize"`
+	Iterations int       `yaml:"iterations"`
+	Throughput []float64 `yaml:"throughput"`
+}
+
+// ExamplesPerIteration returns the batch size of the job, treating jobs without one as processing one
+// example per iteration
+func (cf *JobCustomFields) ExamplesPerIteration() int {
+	if cf.BatchSize <= 0 {
+		return 1
+	}
+	return cf.BatchSize
+}
+
+// JobState is the state policies estimating throughput keep for each job between sessions
+type JobState struct {
+	// Profile is the throughput of the job measured from its progress
+	Profile Profile `yaml:"profile"`
+}
+
+const (
+	// defaultThroughputKey is the throughput curve used for jobs that do not specify one and have not
+	// been profiled yet
+	defaultThroughputKey = "defaultThroughput"
+	// throughputModelKey is the scaling model fitted to the profiled throughput of jobs, Amdahl's law by
+	// default
+	throughputModelKey = "throughputModel"
+)
+
+// Estimator estimates the throughput of jobs with JobCustomFields and JobState
+type Estimator struct {
+	defaultThroughput []float64
+	throughputModel   Model
+}
+
+// NewEstimator returns the estimator configured by the defaultThroughput and throughputModel arguments
+// of a policy
+func NewEstimator(arguments session.Arguments) (Estimator, error) {
+	estimator := Estimator{}
+	if err := arguments.GetFloat64List(&estimator.defaultThroughput, defaultThroughputKey); err != nil {
+		return Estimator{}, err
+	}
+	for _, throughput := range estimator.defaultThroughput {
+		if throughput <= 0 {
+			return Estimator{}, fmt.Errorf("argument %s must only contain positive values", defaultThroughputKey)
+		}
+	}
+	if model, found := arguments[throughputModelKey]; found {
+		var err error
+		if estimator.throughputModel, err = ParseModel(model); err != nil {
+			return Estimator{}, fmt.Errorf("argument %s: %v", throughputModelKey, err)
+		}
+	}
+	return estimator, nil
+}
+
+// Throughput returns the # examples per second the job processes at each # replicas. Jobs without a
+// throughput curve use the curve profiled so far. Jobs that have not been profiled yet use the default
+// throughput, or are given a single replica to be profiled with.
+func (e *Estimator) Throughput(job *info.JobInfo, maxReplicas int) []float64 {
+	customFields := job.CustomFields.(*JobCustomFields)
+	if len(customFields.Throughput) > 0 {
+		return customFields.Throughput
+	}
+
+	profile := &job.State.(*JobState).Profile
+	if iterationRates := profile.Curve(e.throughputModel, maxReplicas); iterationRates != nil {
+		throughput := make([]float64, len(iterationRates))
+		for i, rate := range iterationRates {
+			throughput[i] = rate * float64(customFields.ExamplesPerIteration())
+		}
+		return throughput
+	}
+
+	if len(e.defaultThroughput) > 0 {
+		return e.defaultThroughput
+	}
+	return []float64{1}
+}
@@ -0,0 +1,178 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintacache "github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+)
+
+// ProgressPath is the path jobs report their progress to, followed by "<namespace>/<name>" of the job
+const ProgressPath = "/progress/"
+
+const (
+	// maxProgressSize is the # bytes of a progress report read at most
+	maxProgressSize = 4 << 10
+	// tokenReviewTTL is how long an authenticated token is trusted before it is reviewed again, so that
+	// jobs reporting often do not cost a TokenReview each
+	tokenReviewTTL = time.Minute
+)
+
+// progressRequest is the body of a progress report
+type progressRequest struct {
+	CompletedIterations *int `json:"completedIterations"`
+}
+
+// NewProgressHandler returns the HTTP handler that records the progress jobs report in the cache. Jobs
+// report with POST or PUT to ProgressPath + "<namespace>/<name>" and a JSON body such as
+// {"completedIterations": 100}. Policies read the latest report from JobInfo.Progress.
+//
+// Reports must carry the token of a service account of the namespace of the job, such as the one
+// mounted into the pods of the job, as "Authorization: Bearer <token>". Tokens are verified by the
+// API server with a TokenReview, whose result is cached for tokenReviewTTL.
+func NewProgressHandler(cache pintacache.Cache, client kubernetes.Interface) http.Handler {
+	reviewer := newTokenReviewer(client)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, ProgressPath), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			http.Error(w, fmt.Sprintf("expected %s<namespace>/<name>", ProgressPath), http.StatusNotFound)
+			return
+		}
+		jobID := info.JobID(parts[0] + "/" + parts[1])
+
+		if status, err := reviewer.authenticate(r, parts[0]); err != nil {
+			klog.V(3).Infof("Rejected progress report of job <%s>: %v", jobID, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		var req progressRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxProgressSize)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid progress: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.CompletedIterations == nil || *req.CompletedIterations < 0 {
+			http.Error(w, "completedIterations must be given and not negative", http.StatusBadRequest)
+			return
+		}
+
+		err := cache.ReportProgress(jobID, info.JobProgress{
+			CompletedIterations: *req.CompletedIterations,
+			ReportTime:          metav1.Now(),
+		})
+		if errors.Is(err, pintacache.ErrJobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		klog.V(4).Infof("Job <%s> reported %d completed iterations", jobID, *req.CompletedIterations)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// tokenReviewer authenticates bearer tokens with TokenReviews, and remembers the users of the tokens it
+// authenticated by the hash of the token
+type tokenReviewer struct {
+	client kubernetes.Interface
+
+	mutex sync.Mutex
+	users map[[sha256.Size]byte]reviewedUser
+}
+
+// reviewedUser is the user a token was authenticated as, until expires
+type reviewedUser struct {
+	username string
+	expires  time.Time
+}
+
+func newTokenReviewer(client kubernetes.Interface) *tokenReviewer {
+	return &tokenReviewer{
+		client: client,
+		users:  make(map[[sha256.Size]byte]reviewedUser),
+	}
+}
+
+// authenticate verifies that the bearer token of the request belongs to a service account of the
+// namespace, and returns the HTTP status to reply with otherwise
+func (tr *tokenReviewer) authenticate(r *http.Request, namespace string) (int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return http.StatusUnauthorized, fmt.Errorf("expected a bearer token")
+	}
+
+	username, err := tr.review(token)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to review token: %v", err)
+	}
+	if username == "" {
+		return http.StatusUnauthorized, fmt.Errorf("invalid token")
+	}
+	prefix := "system:serviceaccount:" + namespace + ":"
+	if !strings.HasPrefix(username, prefix) {
+		return http.StatusForbidden, fmt.Errorf("%s cannot report progress of jobs in namespace %s",
+			username, namespace)
+	}
+	return http.StatusOK, nil
+}
+
+// review returns the user the token authenticates as, or "" if it does not authenticate. Only tokens
+// that authenticate are cached, so that invalid tokens cannot fill the cache.
+func (tr *tokenReviewer) review(token string) (string, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+	tr.mutex.Lock()
+	user, found := tr.users[key]
+	tr.mutex.Unlock()
+	if found && now.Before(user.expires) {
+		return user.username, nil
+	}
+
+	review, err := tr.client.AuthenticationV1().TokenReviews().Create(context.TODO(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	if !review.Status.Authenticated {
+		return "", nil
+	}
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	for k, u := range tr.users {
+		if !now.Before(u.expires) {
+			delete(tr.users, k)
+		}
+	}
+	tr.users[key] = reviewedUser{
+		username: review.Status.User.Username,
+		expires:  now.Add(tokenReviewTTL),
+	}
+	return review.Status.User.Username, nil
+}
+
+// ProgressHandler returns the HTTP handler jobs report their progress to
+func (pc *Scheduler) ProgressHandler() http.Handler {
+	return NewProgressHandler(pc.cache, pc.cache.Client())
+}
@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	pintacache "github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeTokenReviews returns a client that authenticates the tokens as the given users, and counts the
+// TokenReviews in numReviews if it is not nil
+func fakeTokenReviews(users map[string]string, numReviews *int) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if numReviews != nil {
+			*numReviews++
+		}
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if user, found := users[review.Spec.Token]; found {
+			review.Status.Authenticated = true
+			review.Status.User.Username = user
+		}
+		return true, review, nil
+	})
+	return client
+}
+
+func TestProgressHandler(t *testing.T) {
+	mc := pintacache.NewMemoryCache()
+	mc.AddJob(&pintav1.PintaJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "j1", Namespace: "default"},
+		Status:     []pintav1.PintaJobStatus{{State: pintav1.Running}},
+	})
+	handler := NewProgressHandler(mc, fakeTokenReviews(map[string]string{
+		"default-token": "system:serviceaccount:default:default",
+		"other-token":   "system:serviceaccount:other:default",
+		"user-token":    "alice",
+	}, nil))
+
+	tests := []struct {
+		method   string
+		path     string
+		token    string
+		body     string
+		expected int
+	}{
+		{http.MethodPost, "/progress/default/j1", "default-token", `{"completedIterations": 100}`, http.StatusNoContent},
+		{http.MethodPut, "/progress/default/j1", "default-token", `{"completedIterations": 200}`, http.StatusNoContent},
+		{http.MethodGet, "/progress/default/j1", "default-token", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/progress/default/j2", "default-token", `{"completedIterations": 100}`, http.StatusNotFound},
+		{http.MethodPost, "/progress/j1", "default-token", `{"completedIterations": 100}`, http.StatusNotFound},
+		{http.MethodPost, "/progress/default/j1", "default-token", `{"completedIterations": -1}`, http.StatusBadRequest},
+		{http.MethodPost, "/progress/default/j1", "default-token", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/progress/default/j1", "default-token", `100`, http.StatusBadRequest},
+		{http.MethodPost, "/progress/default/j1", "default-token",
+			`{"completedIterations": 300, "padding": "` + strings.Repeat("x", maxProgressSize) + `"}`, http.StatusBadRequest},
+		{http.MethodPost, "/progress/default/j1", "", `{"completedIterations": 300}`, http.StatusUnauthorized},
+		{http.MethodPost, "/progress/default/j1", "forged-token", `{"completedIterations": 300}`, http.StatusUnauthorized},
+		{http.MethodPost, "/progress/default/j1", "other-token", `{"completedIterations": 300}`, http.StatusForbidden},
+		{http.MethodPost, "/progress/default/j1", "user-token", `{"completedIterations": 300}`, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != test.expected {
+			t.Errorf("%s %s %s %s: expected status %d, got %d",
+				test.method, test.path, test.token, test.body, test.expected, recorder.Code)
+		}
+	}
+
+	// The latest report is visible to policies
+	snapshot := mc.Snapshot(nil)
+	if job := snapshot.Jobs["default/j1"]; job == nil || job.CompletedIterations() != 200 {
+		t.Errorf("Expected job default/j1 to have completed 200 iterations, got %+v", job)
+	}
+}
+
+func TestProgressHandler_TokenReviewCache(t *testing.T) {
+	mc := pintacache.NewMemoryCache()
+	mc.AddJob(&pintav1.PintaJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "j1", Namespace: "default"},
+		Status:     []pintav1.PintaJobStatus{{State: pintav1.Running}},
+	})
+	numReviews := 0
+	handler := NewProgressHandler(mc, fakeTokenReviews(map[string]string{
+		"default-token": "system:serviceaccount:default:default",
+	}, &numReviews))
+
+	tests := []struct {
+		token      string
+		expected   int
+		numReviews int
+	}{
+		{"default-token", http.StatusNoContent, 1},
+		// Authenticated tokens are not reviewed again
+		{"default-token", http.StatusNoContent, 1},
+		// Invalid tokens are reviewed every time
+		{"forged-token", http.StatusUnauthorized, 2},
+		{"forged-token", http.StatusUnauthorized, 3},
+	}
+	for i, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/progress/default/j1", strings.NewReader(`{"completedIterations": 100}`))
+		request.Header.Set("Authorization", "Bearer "+test.token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != test.expected || numReviews != test.numReviews {
+			t.Errorf("Report %d: expected status %d after %d TokenReviews, got %d after %d",
+				i, test.expected, test.numReviews, recorder.Code, numReviews)
+		}
+	}
+}
@@ -17,11 +17,14 @@ limitations under the License.
 package scheduler
 
 import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/metrics"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/audit"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/conf"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"os"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
 
@@ -33,35 +36,110 @@ import (
 type Scheduler struct {
 	kubeConfig     *rest.Config
 	cache          pintacache.Cache
-	policy         session.Policy
-	configuration  *conf.Configuration
+	policies       []session.Policy
+	configurations []conf.Configuration
+	shadowPolicies []*shadowPolicy
 	schedulerConf  string
-	schedulePeriod time.Duration
+	loadedConf     string
+	// confModTime is the modification time of the configuration file when it was last read
+	confModTime time.Time
+
+	// A cycle runs once changes settle for scheduleDebounce, but no sooner than scheduleMinInterval after
+	// the previous cycle. Changes that keep coming delay it by at most scheduleMinInterval +
+	// scheduleDebounce after the first of them, and it runs at the latest schedulePeriod after the
+	// previous cycle.
+	schedulePeriod      time.Duration
+	scheduleDebounce    time.Duration
+	scheduleMinInterval time.Duration
+
+	// dryRun is set if the scheduler is in dry-run mode
+	dryRun *dryRunReporter
+	// auditLog is set if the cycles are recorded
+	auditLog *audit.Log
 }
 
-// NewScheduler returns a scheduler
+// NewScheduler returns a scheduler that runs a cycle when the cluster changes, after the changes settle
+// for debounce and at least minInterval after the previous cycle. A cycle runs every period regardless.
 func NewScheduler(
 	config *rest.Config,
 	schedulerConf string,
 	period time.Duration,
+	debounce time.Duration,
+	minInterval time.Duration,
+	stateConfigMap string,
+	enablePriorityClass bool,
 ) (*Scheduler, error) {
 	scheduler := &Scheduler{
-		kubeConfig:     config,
-		schedulerConf:  schedulerConf,
-		cache:          pintacache.New(config),
-		schedulePeriod: period,
+		kubeConfig:          config,
+		schedulerConf:       schedulerConf,
+		cache:               pintacache.New(config, stateConfigMap, enablePriorityClass),
+		schedulePeriod:      period,
+		scheduleDebounce:    debounce,
+		scheduleMinInterval: minInterval,
 	}
 
 	return scheduler, nil
 }
 
+// EnableAuditLog makes the scheduler append the snapshot, the policies and the allocations of each cycle
+// to the file at path, rotated once it grows beyond maxSize bytes with maxBackups rotated files kept
+func (pc *Scheduler) EnableAuditLog(path string, maxSize int64, maxBackups int) error {
+	auditLog, err := audit.NewLog(path, maxSize, maxBackups)
+	if err != nil {
+		return err
+	}
+	pc.auditLog = auditLog
+	return nil
+}
+
 // Run runs the Scheduler
 func (pc *Scheduler) Run(stopCh <-chan struct{}) {
 	// Start cache for policy.
 	go pc.cache.Run(stopCh)
 	pc.cache.WaitForCacheSync(stopCh)
 
-	go wait.Until(pc.runOnce, pc.schedulePeriod, stopCh)
+	go pc.loop(stopCh)
+}
+
+// loop runs cycles until stopCh is closed, each one once the changes signaled by the cache settle or the
+// schedule period elapses
+func (pc *Scheduler) loop(stopCh <-chan struct{}) {
+	changes := pc.cache.Changes()
+	for {
+		start := time.Now()
+		pc.runOnce()
+
+		fallback := start.Add(pc.schedulePeriod)
+		next := fallback
+		// firstChange is when the first change since the cycle arrived
+		var firstChange time.Time
+		for waiting := true; waiting; {
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-stopCh:
+				timer.Stop()
+				return
+			case <-changes:
+				timer.Stop()
+				now := time.Now()
+				if firstChange.IsZero() {
+					firstChange = now
+				}
+				next = now.Add(pc.scheduleDebounce)
+				if earliest := start.Add(pc.scheduleMinInterval); next.Before(earliest) {
+					next = earliest
+				}
+				if latest := firstChange.Add(pc.scheduleMinInterval + pc.scheduleDebounce); next.After(latest) {
+					next = latest
+				}
+				if next.After(fallback) {
+					next = fallback
+				}
+			case <-timer.C:
+				waiting = false
+			}
+		}
+	}
 }
 
 func (pc *Scheduler) runOnce() {
@@ -70,20 +148,61 @@ func (pc *Scheduler) runOnce() {
 
 	pc.loadSchedulerConf()
 
-	policy := pc.policy
+	policies := pc.policies
 
-	ssn := session.OpenSession(pc.kubeConfig, pc.cache, policy)
+	ssn := session.OpenSession(pc.kubeConfig, pc.cache, policies)
+	if pc.dryRun != nil {
+		ssn.DryRun(pc.dryRun)
+	}
 	defer session.CloseSession(ssn)
 
-	policy.Execute(ssn)
+	// Shadow policies and the audit log start from the same snapshot as the pipeline
+	var snapshot *info.ClusterInfo
+	if len(pc.shadowPolicies) > 0 || pc.auditLog != nil {
+		snapshot = ssn.Snapshot()
+	}
+	var record *audit.Record
+	if pc.auditLog != nil {
+		record = audit.Capture(ssn, snapshot, policies, pc.configurations, pc.cache.JobStateStore())
+	}
+
+	session.ExecutePolicies(ssn, policies)
+
+	if record != nil {
+		record.Complete(ssn)
+		if err := pc.auditLog.Write(record); err != nil {
+			klog.Errorf("Failed to record scheduling cycle: %v", err)
+		}
+	}
+
+	metrics.ResetJobEstimates()
+	for id, estimate := range ssn.Estimates() {
+		metrics.UpdateJobEstimate(string(id), estimate.Start, estimate.Completion)
+	}
+
+	if len(pc.shadowPolicies) > 0 {
+		summary := ssn.Summarize()
+		metrics.UpdateAllocation(summary.NumNodesUsed, summary.NumJobsStarved)
+		for _, shadowPolicy := range pc.shadowPolicies {
+			shadowPolicy.evaluate(ssn, summary, snapshot.Clone())
+		}
+	}
 }
 
 func (pc *Scheduler) loadSchedulerConf() {
 	var err error
 
-	// Load configuration of scheduler
+	// Load configuration of scheduler, unless the file is unchanged since it was last read
 	schedConf := defaultSchedulerConf
 	if len(pc.schedulerConf) != 0 {
+		var modTime time.Time
+		if fileInfo, err := os.Stat(pc.schedulerConf); err == nil {
+			modTime = fileInfo.ModTime()
+			if pc.policies != nil && modTime.Equal(pc.confModTime) {
+				return
+			}
+		}
+		pc.confModTime = modTime
 		if schedConf, err = readSchedulerConf(pc.schedulerConf); err != nil {
 			klog.Errorf("Failed to read scheduler configuration '%s', using default configuration: %v",
 				pc.schedulerConf, err)
@@ -91,8 +210,38 @@ func (pc *Scheduler) loadSchedulerConf() {
 		}
 	}
 
-	pc.policy, pc.configuration, err = loadSchedulerConf(schedConf)
+	// Policies are only rebuilt when the configuration changes
+	if pc.policies != nil && schedConf == pc.loadedConf {
+		return
+	}
+
+	policies, configurations, err := loadSchedulerConf(schedConf)
+	var shadowPolicies []session.Policy
+	if err == nil {
+		shadowPolicies, err = loadShadowPolicies(schedConf)
+	}
 	if err != nil {
-		panic(err)
+		if pc.policies == nil {
+			panic(err)
+		}
+		klog.Errorf("Failed to load scheduler configuration, keeping previous configuration: %v", err)
+		return
+	}
+
+	for _, policy := range pc.policies {
+		policy.UnInitialize()
+	}
+	for _, shadowPolicy := range pc.shadowPolicies {
+		shadowPolicy.policy.UnInitialize()
+	}
+	metrics.ResetShadowPolicies()
+	for _, policy := range policies {
+		policy.Initialize()
+	}
+	pc.shadowPolicies = make([]*shadowPolicy, 0, len(shadowPolicies))
+	for _, policy := range shadowPolicies {
+		policy.Initialize()
+		pc.shadowPolicies = append(pc.shadowPolicies, newShadowPolicy(policy))
 	}
+	pc.policies, pc.configurations, pc.loadedConf = policies, configurations, schedConf
 }
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	pintacache "github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScheduler_Loop(t *testing.T) {
+	mc := pintacache.NewMemoryCache()
+	pc := &Scheduler{
+		cache:            mc,
+		schedulePeriod:   time.Hour,
+		scheduleDebounce: 10 * time.Millisecond,
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go pc.loop(stopCh)
+
+	// A new job is scheduled long before the schedule period elapses
+	mc.AddNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n0"},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	})
+	mc.AddJob(&pintav1.PintaJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "j1",
+			Namespace:   "default",
+			Annotations: map[string]string{"pinta.qed.usc.edu/custom-fields": "{numReplicas: 1}"},
+		},
+		Spec:   pintav1.PintaJobSpec{Type: pintav1.Symmetric},
+		Status: []pintav1.PintaJobStatus{{State: pintav1.Idle}},
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if status, found := mc.StatusSink.LastStatus("default/j1"); found && status.NumReplicas == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected job default/j1 to be scheduled after it is added")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScheduler_LoopWithoutPause(t *testing.T) {
+	mc := pintacache.NewMemoryCache()
+	pc := &Scheduler{
+		cache:            mc,
+		schedulePeriod:   time.Hour,
+		scheduleDebounce: 200 * time.Millisecond,
+	}
+	addNode := func(name string) {
+		mc.AddNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		})
+	}
+	addJob := func(name string) {
+		mc.AddJob(&pintav1.PintaJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: map[string]string{"pinta.qed.usc.edu/custom-fields": "{numReplicas: 1}"},
+			},
+			Spec:   pintav1.PintaJobSpec{Type: pintav1.Symmetric},
+			Status: []pintav1.PintaJobStatus{{State: pintav1.Idle}},
+		})
+	}
+	scheduled := func(id info.JobID) bool {
+		status, found := mc.StatusSink.LastStatus(id)
+		return found && status.NumReplicas == 1
+	}
+
+	// The first cycle runs right away
+	addNode("n0")
+	addJob("j1")
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go pc.loop(stopCh)
+	deadline := time.Now().Add(5 * time.Second)
+	for !scheduled("default/j1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected job default/j1 to be scheduled by the first cycle")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Changes that never settle for the debounce still let a cycle run
+	addJob("j2")
+	deadline = time.Now().Add(5 * time.Second)
+	for i := 1; !scheduled("default/j2"); i++ {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected job default/j2 to be scheduled while nodes keep changing")
+		}
+		addNode(fmt.Sprintf("n%d", i))
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Arguments are the arguments given to a policy in the scheduler configuration
+type Arguments map[string]string
+
+// GetInt parses the argument as an int into ptr. ptr is left unchanged if the argument is not given.
+func (a Arguments) GetInt(ptr *int, key string) error {
+	value, found := a[key]
+	if !found {
+		return nil
+	}
+
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("argument %s: cannot parse %q as int", key, value)
+	}
+	*ptr = i
+	return nil
+}
+
+// GetFloat64 parses the argument as a float64 into ptr. ptr is left unchanged if the argument is not given.
+func (a Arguments) GetFloat64(ptr *float64, key string) error {
+	value, found := a[key]
+	if !found {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return fmt.Errorf("argument %s: cannot parse %q as float", key, value)
+	}
+	*ptr = f
+	return nil
+}
+
+// GetBool parses the argument as a bool into ptr. ptr is left unchanged if the argument is not given.
+func (a Arguments) GetBool(ptr *bool, key string) error {
+	value, found := a[key]
+	if !found {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("argument %s: cannot parse %q as bool", key, value)
+	}
+	*ptr = b
+	return nil
+}
+
+// GetStringList parses the argument as a comma separated list into ptr. ptr is left unchanged if the
+// argument is not given.
+func (a Arguments) GetStringList(ptr *[]string, key string) error {
+	value, found := a[key]
+	if !found {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return fmt.Errorf("argument %s: empty item in %q", key, value)
+		}
+		list = append(list, item)
+	}
+	*ptr = list
+	return nil
+}
+
+// GetFloat64List parses the argument as a comma separated list of float64 into ptr. ptr is left unchanged
+// if the argument is not given.
+func (a Arguments) GetFloat64List(ptr *[]float64, key string) error {
+	var items []string
+	if err := a.GetStringList(&items, key); err != nil || items == nil {
+		return err
+	}
+
+	list := make([]float64, 0, len(items))
+	for _, item := range items {
+		f, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return fmt.Errorf("argument %s: cannot parse %q as float", key, item)
+		}
+		list = append(list, f)
+	}
+	*ptr = list
+	return nil
+}
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"k8s.io/klog"
+	"sort"
+)
+
+// Summary is derived from the allocations of the jobs of a session
+type Summary struct {
+	// NumNodesUsed is the # nodes the allocated masters and replicas are bin-packed onto
+	NumNodesUsed int `json:"numNodesUsed"`
+	// NumJobsStarved is the # jobs allocated nothing
+	NumJobsStarved int `json:"numJobsStarved"`
+}
+
+// Snapshot returns a deep copy of the jobs, nodes and queues of the session, including the jobs hidden by
+// policies. Taken before the policies are executed, it is the snapshot the session started from.
+func (ssn *Session) Snapshot() *info.ClusterInfo {
+	snapshot := &info.ClusterInfo{
+		Jobs:   ssn.jobs,
+		Nodes:  ssn.Nodes,
+		Queues: ssn.Queues,
+	}
+	return snapshot.Clone()
+}
+
+// Summarize returns the summary of the current allocations of the jobs
+func (ssn *Session) Summarize() Summary {
+	var summary Summary
+	pool := NewNodePool(ssn)
+	for _, job := range ssn.jobs {
+		if job.NumMasters+job.NumReplicas == 0 {
+			summary.NumJobsStarved++
+			continue
+		}
+		if !pool.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+			klog.V(4).Infof("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+		}
+	}
+	summary.NumNodesUsed = pool.numNodesUsed()
+	return summary
+}
+
+// Diff returns the jobs other allocates differently than the session, in order of job ID. Jobs that are
+// not in both sessions are ignored.
+func (ssn *Session) Diff(other *Session) []JobDiff {
+	var diffs []JobDiff
+	for id, job := range ssn.jobs {
+		otherJob, found := other.jobs[id]
+		if !found || (job.NumMasters == otherJob.NumMasters && job.NumReplicas == otherJob.NumReplicas) {
+			continue
+		}
+		explanation := other.Explanation(otherJob)
+		diffs = append(diffs, JobDiff{
+			Job:   id,
+			State: lastStatus(job.Job).State,
+
+			NumMasters:          job.NumMasters,
+			NumReplicas:         job.NumReplicas,
+			ProposedNumMasters:  otherJob.NumMasters,
+			ProposedNumReplicas: otherJob.NumReplicas,
+
+			Reason:  explanation.Reason,
+			Message: explanation.Message,
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Job < diffs[j].Job
+	})
+	return diffs
+}
+
+// Allocation is what a job is allocated and why
+type Allocation struct {
+	Job         info.JobID `json:"job"`
+	NumMasters  int32      `json:"numMasters"`
+	NumReplicas int32      `json:"numReplicas"`
+	Reason      string     `json:"reason,omitempty"`
+	Message     string     `json:"message,omitempty"`
+}
+
+// Allocations returns the current allocations of the jobs, including the jobs hidden by policies, in order
+// of job ID
+func (ssn *Session) Allocations() []Allocation {
+	allocations := make([]Allocation, 0, len(ssn.jobs))
+	for id, job := range ssn.jobs {
+		explanation := ssn.Explanation(job)
+		allocations = append(allocations, Allocation{
+			Job:         id,
+			NumMasters:  job.NumMasters,
+			NumReplicas: job.NumReplicas,
+			Reason:      explanation.Reason,
+			Message:     explanation.Message,
+		})
+	}
+	sort.Slice(allocations, func(i, j int) bool {
+		return allocations[i].Job < allocations[j].Job
+	})
+	return allocations
+}
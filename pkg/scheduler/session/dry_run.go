@@ -0,0 +1,107 @@
+package session
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+)
+
+// JobDiff is the allocation proposed for a job against a reference allocation: the current status of the
+// job for sessions in dry-run mode, or the allocation of the policies pipeline for shadow policies
+type JobDiff struct {
+	Job   info.JobID            `json:"job"`
+	State pintav1.PintaJobState `json:"state,omitempty"`
+
+	NumMasters          int32 `json:"numMasters"`
+	NumReplicas         int32 `json:"numReplicas"`
+	ProposedNumMasters  int32 `json:"proposedNumMasters"`
+	ProposedNumReplicas int32 `json:"proposedNumReplicas"`
+
+	// Reason and Message explain the proposed allocation
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// DryRunResult is what a session in dry-run mode would have committed
+type DryRunResult struct {
+	Session types.UID   `json:"session"`
+	Time    metav1.Time `json:"time"`
+
+	// Changes are the jobs whose allocation would change, in order of job ID
+	Changes []JobDiff `json:"changes"`
+	// NumUnchanged is the # jobs whose allocation would stay the same
+	NumUnchanged int `json:"numUnchanged"`
+}
+
+// DryRunReporter receives the results of sessions in dry-run mode
+type DryRunReporter interface {
+	Report(result *DryRunResult)
+}
+
+// DryRun puts the session in dry-run mode, where CloseSession reports the allocations the policies
+// propose to reporter instead of committing them, and the job states the policies save are dropped. It
+// must be called before the policies are executed.
+func (ssn *Session) DryRun(reporter DryRunReporter) {
+	ssn.dryRunReporter = reporter
+	ssn.jobStateStore = &dryRunJobStateStore{
+		store: ssn.jobStateStore,
+		saved: cache.NewMemoryJobStateStore(),
+	}
+}
+
+// dryRunResult compares the allocation of each job in the session with its current status
+func (ssn *Session) dryRunResult() *DryRunResult {
+	result := &DryRunResult{
+		Session: ssn.UID,
+		Time:    metav1.NewTime(ssn.now),
+	}
+	for id, job := range ssn.jobs {
+		status := lastStatus(job.Job)
+		if job.NumMasters == status.NumMasters && job.NumReplicas == status.NumReplicas {
+			result.NumUnchanged++
+			continue
+		}
+		explanation := ssn.Explanation(job)
+		result.Changes = append(result.Changes, JobDiff{
+			Job:   id,
+			State: status.State,
+
+			NumMasters:          status.NumMasters,
+			NumReplicas:         status.NumReplicas,
+			ProposedNumMasters:  job.NumMasters,
+			ProposedNumReplicas: job.NumReplicas,
+
+			Reason:  explanation.Reason,
+			Message: explanation.Message,
+		})
+	}
+	sort.Slice(result.Changes, func(i, j int) bool {
+		return result.Changes[i].Job < result.Changes[j].Job
+	})
+	return result
+}
+
+// dryRunJobStateStore reads the states of the store behind it, but keeps the states saved to it for the
+// session only
+type dryRunJobStateStore struct {
+	store cache.JobStateStore
+	saved *cache.MemoryJobStateStore
+}
+
+func (s *dryRunJobStateStore) Get(jobID info.JobID, policy string) (string, bool, error) {
+	if state, found, _ := s.saved.Get(jobID, policy); found {
+		return state, true, nil
+	}
+	return s.store.Get(jobID, policy)
+}
+
+func (s *dryRunJobStateStore) Set(jobID info.JobID, policy string, state string) {
+	s.saved.Set(jobID, policy, state)
+}
+
+func (s *dryRunJobStateStore) Flush(jobIDs []info.JobID) error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// estimateTolerance is how far an estimate may move before the status of the job is updated, so that
+// estimates drifting with each session do not cause an update every session
+const estimateTolerance = time.Minute
+
+// Estimate is when a job is expected to start and complete. Times are zero if unknown.
+type Estimate struct {
+	Start      time.Time
+	Completion time.Time
+}
+
+// EstimateStart sets the time the waiting job is expected to start at
+func (ssn *Session) EstimateStart(job *info.JobInfo, start time.Time) {
+	estimate := ssn.estimates[job.UID]
+	estimate.Start = start
+	ssn.estimates[job.UID] = estimate
+}
+
+// EstimateCompletion sets the time the job is expected to complete at
+func (ssn *Session) EstimateCompletion(job *info.JobInfo, completion time.Time) {
+	estimate := ssn.estimates[job.UID]
+	estimate.Completion = completion
+	ssn.estimates[job.UID] = estimate
+}
+
+// Estimates returns the estimates policies set for the jobs
+func (ssn *Session) Estimates() map[info.JobID]Estimate {
+	estimates := make(map[info.JobID]Estimate, len(ssn.estimates))
+	for id, estimate := range ssn.estimates {
+		estimates[id] = estimate
+	}
+	return estimates
+}
+
+// estimateTime returns the estimated time to record in the status of a job, nil if unknown
+func estimateTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	estimate := metav1.NewTime(t)
+	return &estimate
+}
+
+// estimateChanged returns whether the estimated time moved by more than estimateTolerance
+func estimateChanged(old, new *metav1.Time) bool {
+	if old == nil || new == nil {
+		return old != new
+	}
+	d := new.Sub(old.Time)
+	return d > estimateTolerance || d < -estimateTolerance
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Reasons policies give for the allocations of jobs
+const (
+	// ReasonScheduled is given to jobs allocated masters and replicas
+	ReasonScheduled = "Scheduled"
+	// ReasonNotScheduled is given to jobs allocated nothing without a more specific reason
+	ReasonNotScheduled = "NotScheduled"
+	// ReasonQueued is given to jobs waiting for the jobs ahead of them in a queue
+	ReasonQueued = "Queued"
+	// ReasonOutranked is given to jobs that lose the resources to jobs the policy ranks higher
+	ReasonOutranked = "Outranked"
+	// ReasonInsufficientResources is given to jobs that do not fit in the free resources
+	ReasonInsufficientResources = "InsufficientResources"
+	// ReasonPreempted is given to jobs that lose their resources to jobs of higher priority
+	ReasonPreempted = "Preempted"
+	// ReasonQueueLimit is given to jobs whose PintaQueue is at its limit
+	ReasonQueueLimit = "QueueLimitReached"
+	// ReasonBelowMinGangSize is given to jobs stopped for being allocated fewer masters or replicas than
+	// their minimums
+	ReasonBelowMinGangSize = "BelowMinGangSize"
+)
+
+// Explanation is why a job is allocated what it is
+type Explanation struct {
+	// Reason is machine-readable, one of the Reason constants
+	Reason string
+	// Message is for humans
+	Message string
+}
+
+// Explainf attaches the reason for the allocation of the job, replacing the one attached before, such as
+// by policies before in the pipeline
+func (ssn *Session) Explainf(job *info.JobInfo, reason string, format string, args ...interface{}) {
+	ssn.explanations[job.UID] = Explanation{
+		Reason:  reason,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Explanation returns the reason attached for the allocation of the job, or a default one if there is
+// none
+func (ssn *Session) Explanation(job *info.JobInfo) Explanation {
+	if explanation, found := ssn.explanations[job.UID]; found {
+		return explanation
+	}
+	if job.NumMasters+job.NumReplicas > 0 {
+		return Explanation{
+			Reason:  ReasonScheduled,
+			Message: fmt.Sprintf("allocated %d masters and %d replicas", job.NumMasters, job.NumReplicas),
+		}
+	}
+	return Explanation{
+		Reason:  ReasonNotScheduled,
+		Message: "no policy allocated resources to the job",
+	}
+}
+
+// eventType returns the type of the Kubernetes Event for the reason
+func eventType(reason string) string {
+	switch reason {
+	case ReasonInsufficientResources, ReasonPreempted, ReasonBelowMinGangSize:
+		return v1.EventTypeWarning
+	}
+	return v1.EventTypeNormal
+}
+
+// Shortage describes what the pool lacks to place numMasters masters and numReplicas replicas of the job
+func (np *NodePool) Shortage(job *info.JobInfo, numMasters, numReplicas int32) string {
+	if numMasters > 0 && !np.Clone().AllocateJob(job, numMasters, 0) {
+		return fmt.Sprintf("%s exhausted, %d masters do not fit", nodesOfType(job.MasterNodeType), numMasters)
+	}
+	return fmt.Sprintf("%s exhausted, %d of %d replicas fit",
+		nodesOfType(job.ReplicaNodeType), np.MaxReplicas(job, numMasters), numReplicas)
+}
+
+func nodesOfType(nodeType string) string {
+	if nodeType == "" {
+		return "cluster"
+	}
+	return "node type " + nodeType
+}
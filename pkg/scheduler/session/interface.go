@@ -1,8 +1,12 @@
 package session
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"sync"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/conf"
 )
 
 type Policy interface {
@@ -13,24 +17,56 @@ type Policy interface {
 	UnInitialize()
 }
 
+// StatefulPolicy is a policy that keeps state for each job between sessions. Before the policy is
+// executed, JobInfo.State of each job is set to the state saved last time, or to a new value of
+// JobStateType if there is none. The states are saved after the policy is executed.
+type StatefulPolicy interface {
+	Policy
+	JobStateType() reflect.Type
+}
+
+// PolicyBuilder builds a policy with the given arguments. It returns an error if the arguments are invalid.
+type PolicyBuilder func(arguments Arguments) (Policy, error)
+
 var policyMutex sync.Mutex
 
 // *Policy management
-var policyMap = map[string]Policy{}
+var policyBuilders = map[string]PolicyBuilder{}
 
-// RegisterPolicy register policy
-func RegisterPolicy(policy Policy) {
+// RegisterPolicyBuilder register policy builder
+func RegisterPolicyBuilder(name string, builder PolicyBuilder) {
 	policyMutex.Lock()
 	defer policyMutex.Unlock()
 
-	policyMap[policy.Name()] = policy
+	policyBuilders[name] = builder
 }
 
-// GetPolicy get the policy by name
-func GetPolicy(name string) (Policy, bool) {
+// GetPolicyBuilder get the policy builder by name
+func GetPolicyBuilder(name string) (PolicyBuilder, bool) {
 	policyMutex.Lock()
 	defer policyMutex.Unlock()
 
-	policy, found := policyMap[name]
-	return policy, found
+	builder, found := policyBuilders[name]
+	return builder, found
+}
+
+// BuildPolicies builds the registered policies of the options, in order, along with their configurations
+func BuildPolicies(policyOptions []conf.PolicyOption) ([]Policy, []conf.Configuration, error) {
+	policies := make([]Policy, 0, len(policyOptions))
+	configurations := make([]conf.Configuration, 0, len(policyOptions))
+	for _, policyOption := range policyOptions {
+		policyName := policyOption.Name
+		builder, found := GetPolicyBuilder(strings.TrimSpace(policyName))
+		if !found {
+			return nil, nil, fmt.Errorf("failed to found Policy %s", policyName)
+		}
+		policy, err := builder(policyOption.Configuration.Arguments)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid arguments for Policy %s: %v", policyName, err)
+		}
+		policies = append(policies, policy)
+		configurations = append(configurations, policyOption.Configuration)
+	}
+
+	return policies, configurations, nil
 }
@@ -19,8 +19,8 @@ type jobUpdater struct {
 }
 
 func newJobUpdater(ssn *Session) *jobUpdater {
-	queue := make([]*info.JobInfo, 0, len(ssn.Jobs))
-	for _, jobInfo := range ssn.Jobs {
+	queue := make([]*info.JobInfo, 0, len(ssn.jobs))
+	for _, jobInfo := range ssn.jobs {
 		queue = append(queue, jobInfo)
 	}
 
@@ -39,29 +39,60 @@ func (ju *jobUpdater) updateJob(index int) {
 	var err error
 	jobInfo := ju.jobQueue[index]
 	job := jobInfo.Job
-	pinta := ju.ssn.cache.PintaClient().PintaV1()
 
-	var lastPintaJobStatus pintav1.PintaJobStatus
-	if len(job.Status) > 0 {
-		lastPintaJobStatus = job.Status[0]
-	}
+	lastPintaJobStatus := lastStatus(job)
+	explanation := ju.ssn.Explanation(jobInfo)
+	allocationChanged := jobInfo.NumMasters != lastPintaJobStatus.NumMasters ||
+		jobInfo.NumReplicas != lastPintaJobStatus.NumReplicas
+	reasonChanged := explanation.Reason != lastPintaJobStatus.Reason
+	estimate := ju.ssn.estimates[jobInfo.UID]
+	estimatedStartTime := estimateTime(estimate.Start)
+	estimatedCompletionTime := estimateTime(estimate.Completion)
+	estimateChanged := estimateChanged(lastPintaJobStatus.EstimatedStartTime, estimatedStartTime) ||
+		estimateChanged(lastPintaJobStatus.EstimatedCompletionTime, estimatedCompletionTime)
 	// Update job status
-	// Ignore jobs without changes
-	if jobInfo.NumMasters == lastPintaJobStatus.NumMasters && jobInfo.NumReplicas == lastPintaJobStatus.NumReplicas {
+	// Ignore jobs without changes. Messages carry numbers that move with each session, e.g. remaining
+	// times, so a new message alone does not update the status.
+	if !allocationChanged && !reasonChanged && !estimateChanged {
 		return
 	}
 
-	job.Status = append([]pintav1.PintaJobStatus{
-		{
-			State:              lastPintaJobStatus.State,
-			LastTransitionTime: metav1.Now(),
-			NumMasters:         jobInfo.NumMasters,
-			NumReplicas:        jobInfo.NumReplicas,
-		},
-	}, job.Status...)
+	if allocationChanged || len(job.Status) == 0 {
+		job.Status = append([]pintav1.PintaJobStatus{
+			{
+				State:              lastPintaJobStatus.State,
+				LastTransitionTime: metav1.Now(),
+				NumMasters:         jobInfo.NumMasters,
+				NumReplicas:        jobInfo.NumReplicas,
+				Reason:             explanation.Reason,
+				Message:            explanation.Message,
 
-	_, err = pinta.PintaJobs(jobInfo.Namespace).UpdateStatus(context.TODO(), job, metav1.UpdateOptions{})
+				EstimatedStartTime:      estimatedStartTime,
+				EstimatedCompletionTime: estimatedCompletionTime,
+			},
+		}, job.Status...)
+	} else {
+		// Only the explanation or the estimates changed, which is not a transition of the job
+		job.Status[0].Reason = explanation.Reason
+		job.Status[0].Message = explanation.Message
+		job.Status[0].EstimatedStartTime = estimatedStartTime
+		job.Status[0].EstimatedCompletionTime = estimatedCompletionTime
+	}
+
+	err = ju.ssn.cache.UpdateJobStatus(job)
 	if err != nil {
 		klog.Errorf("Commit failed when updating job status: %v", err)
+		return
+	}
+	if allocationChanged || reasonChanged {
+		ju.ssn.cache.RecordJobEvent(job, eventType(explanation.Reason), explanation.Reason, explanation.Message)
+	}
+}
+
+// lastStatus returns the current status of the job, empty if it has none
+func lastStatus(job *pintav1.PintaJob) pintav1.PintaJobStatus {
+	if len(job.Status) > 0 {
+		return job.Status[0]
 	}
+	return pintav1.PintaJobStatus{}
 }
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	v1 "k8s.io/api/core/v1"
+	"math"
+)
+
+// RoleCustomFields are the custom fields of jobs that ask for a # masters and # replicas. Policies that
+// start jobs with what they ask for use them as their custom fields, or embed them inline.
+type RoleCustomFields struct {
+	NumMasters  *int32 `yaml:"numMasters"`
+	NumReplicas int32  `yaml:"numReplicas"`
+}
+
+func (cf *RoleCustomFields) roleCustomFields() *RoleCustomFields {
+	return cf
+}
+
+// RequestedRoles returns the # masters and # replicas the job asks for in its RoleCustomFields, within
+// its bounds
+func RequestedRoles(job *info.JobInfo) (int32, int32) {
+	customFields := job.CustomFields.(interface{ roleCustomFields() *RoleCustomFields }).roleCustomFields()
+
+	var numMasters int32
+	if customFields.NumMasters != nil {
+		numMasters = *customFields.NumMasters
+	}
+	return job.FitMasters(numMasters), job.FitReplicas(customFields.NumReplicas)
+}
+
+// ArrivedBefore orders jobs first come first served, by UID if they are created at the same time
+func ArrivedBefore(l, r *info.JobInfo) bool {
+	if !l.CreationTimestamp.Equal(&r.CreationTimestamp) {
+		return l.CreationTimestamp.Before(&r.CreationTimestamp)
+	}
+	return l.UID < r.UID
+}
+
+// JobLess orders jobs by priority, highest first, then first come first served
+func JobLess(l, r *info.JobInfo) bool {
+	if l.Priority != r.Priority {
+		return l.Priority > r.Priority
+	}
+	return ArrivedBefore(l, r)
+}
+
+// StillRunning returns the jobs that are not preempted, reusing the slice
+func StillRunning(jobs []*info.JobInfo) []*info.JobInfo {
+	running := jobs[:0]
+	for _, job := range jobs {
+		if job.NumMasters+job.NumReplicas > 0 {
+			running = append(running, job)
+		}
+	}
+	return running
+}
+
+// ShareResourceNames are the resources shares of the cluster are taken over
+var ShareResourceNames = []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, info.GPUResourceName}
+
+// DominantShare returns the largest fraction of the total of a resource that is allocated. Resources
+// the cluster does not have are ignored.
+func DominantShare(allocated, total *info.Resource) float64 {
+	share := 0.0
+	for _, name := range ShareResourceNames {
+		if t := total.Get(name); t > 0 {
+			share = math.Max(share, allocated.Get(name)/t)
+		}
+	}
+	return share
+}
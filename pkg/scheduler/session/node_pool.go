@@ -0,0 +1,302 @@
+package session
+
+import (
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sort"
+)
+
+// NodePool tracks the resources of each node that are not allocated yet, and bin-packs the masters and
+// replicas of jobs onto the nodes. Jobs pinned to a node type only use nodes of that type, while jobs
+// without a node type can use nodes of any type.
+//
+// Roles without resources take a whole node each. Roles with resources, including fractions of a node,
+// share nodes with each other as long as their resources fit. Masters and replicas of several nodes take
+// that many nodes, one pod each.
+type NodePool struct {
+	nodeTypes  map[string]*info.NodeTypeInfo
+	nodes      []*poolNode
+	placements map[info.JobID][]placement
+}
+
+type poolNode struct {
+	name     string
+	nodeType string
+	free     *info.Resource
+	numPods  int
+	// whole is set when the node is taken by a role without resources
+	whole bool
+}
+
+// placement is a pod placed on a node. A nil request takes the whole node.
+type placement struct {
+	node    int
+	request *info.Resource
+}
+
+// roleRequest is what each master or replica of a role takes: numPods pods with the resources of
+// request, or a whole node each if request is nil
+type roleRequest struct {
+	request *info.Resource
+	numPods int
+}
+
+// NewNodePool returns a pool with all nodes in the session free
+func NewNodePool(ssn *Session) *NodePool {
+	np := &NodePool{
+		nodeTypes:  ssn.NodeTypes,
+		nodes:      make([]*poolNode, 0, len(ssn.Nodes)),
+		placements: make(map[info.JobID][]placement),
+	}
+	for _, node := range ssn.Nodes {
+		np.nodes = append(np.nodes, &poolNode{
+			name:     node.Name,
+			nodeType: node.Type,
+			free:     node.Allocatable.Clone(),
+		})
+	}
+	sort.Slice(np.nodes, func(i, j int) bool {
+		return np.nodes[i].name < np.nodes[j].name
+	})
+	return np
+}
+
+// Clone returns a copy of the pool
+func (np *NodePool) Clone() *NodePool {
+	clone := &NodePool{
+		nodeTypes:  np.nodeTypes,
+		nodes:      make([]*poolNode, len(np.nodes)),
+		placements: make(map[info.JobID][]placement, len(np.placements)),
+	}
+	for i, node := range np.nodes {
+		nodeClone := *node
+		nodeClone.free = node.free.Clone()
+		clone.nodes[i] = &nodeClone
+	}
+	for jobID, placements := range np.placements {
+		clone.placements[jobID] = append([]placement(nil), placements...)
+	}
+	return clone
+}
+
+// MaxReplicas returns the max # replicas of the job that fit in the pool together with numMasters masters
+func (np *NodePool) MaxReplicas(job *info.JobInfo, numMasters int32) int {
+	masterRequest, replicaRequest, err := np.requests(job)
+	if err != nil {
+		klog.V(3).Infof("Job <%s/%s> cannot be placed: %v", job.Namespace, job.Name, err)
+		return 0
+	}
+
+	pool := np
+	if numMasters > 0 {
+		pool = np.Clone()
+		if _, ok := pool.place(job.MasterNodeType, masterRequest, int(numMasters)); !ok {
+			return 0
+		}
+	} else if replicaRequest.request != nil {
+		// Placing replicas one by one changes the pool
+		pool = np.Clone()
+	}
+
+	if replicaRequest.request == nil {
+		n := 0
+		for _, node := range pool.nodes {
+			if pool.usable(node, job.ReplicaNodeType) && node.numPods == 0 {
+				n++
+			}
+		}
+		return n / replicaRequest.numPods
+	}
+	n := 0
+	for {
+		if _, ok := pool.place(job.ReplicaNodeType, replicaRequest, 1); !ok {
+			return n
+		}
+		n++
+	}
+}
+
+// AllocateJob places numMasters masters and numReplicas replicas of the job in addition to what the job
+// already has in the pool, and returns whether they fit. Nothing is placed if they do not.
+func (np *NodePool) AllocateJob(job *info.JobInfo, numMasters, numReplicas int32) bool {
+	masterRequest, replicaRequest, err := np.requests(job)
+	if err != nil {
+		klog.V(3).Infof("Job <%s/%s> cannot be placed: %v", job.Namespace, job.Name, err)
+		return false
+	}
+
+	masterPlacements, ok := np.place(job.MasterNodeType, masterRequest, int(numMasters))
+	if !ok {
+		return false
+	}
+	replicaPlacements, ok := np.place(job.ReplicaNodeType, replicaRequest, int(numReplicas))
+	if !ok {
+		np.unplace(masterPlacements)
+		return false
+	}
+	np.placements[job.UID] = append(np.placements[job.UID], masterPlacements...)
+	np.placements[job.UID] = append(np.placements[job.UID], replicaPlacements...)
+	return true
+}
+
+// Preempt places numMasters masters and numReplicas replicas of the job, releasing running jobs it can
+// preempt until they fit, lowest priority first and youngest first within a priority. It returns the
+// preempted jobs, or nil if the job does not fit even then, in which case the pool is left as it was.
+func (np *NodePool) Preempt(job *info.JobInfo, numMasters, numReplicas int32, running []*info.JobInfo) []*info.JobInfo {
+	var candidates []*info.JobInfo
+	for _, r := range running {
+		if CanPreempt(job, r) {
+			candidates = append(candidates, r)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return JobLess(candidates[j], candidates[i])
+	})
+
+	pool := np.Clone()
+	for i, victim := range candidates {
+		pool.Release(victim)
+		if pool.AllocateJob(job, numMasters, numReplicas) {
+			*np = *pool
+			return candidates[:i+1]
+		}
+	}
+	return nil
+}
+
+// KeepNonPreemptible places the running jobs that cannot be preempted with the masters and replicas they
+// run with, and returns which jobs are kept. Policies that allocate from scratch place them first.
+func (np *NodePool) KeepNonPreemptible(jobs map[info.JobID]*info.JobInfo) map[info.JobID]bool {
+	kept := make(map[info.JobID]bool)
+	for id, job := range jobs {
+		if job.Preemptible || job.NumMasters+job.NumReplicas == 0 {
+			continue
+		}
+		if !np.AllocateJob(job, job.NumMasters, job.NumReplicas) {
+			klog.Warningf("Job <%s/%s> does not fit in the resources of the cluster", job.Namespace, job.Name)
+		}
+		kept[id] = true
+	}
+	return kept
+}
+
+// CanPreempt returns whether the preemptor may take the resources of the running victim
+func CanPreempt(preemptor, victim *info.JobInfo) bool {
+	return victim.Preemptible && victim.Priority < preemptor.Priority
+}
+
+// Release frees everything allocated to the job
+func (np *NodePool) Release(job *info.JobInfo) {
+	np.unplace(np.placements[job.UID])
+	delete(np.placements, job.UID)
+}
+
+// Allocated returns the resources allocated to the job, where roles that take a whole node count all
+// the resources of the node
+func (np *NodePool) Allocated(job *info.JobInfo) *info.Resource {
+	allocated := info.EmptyResource()
+	for _, p := range np.placements[job.UID] {
+		if p.request == nil {
+			// Nothing else is placed on the node, so all of it is free otherwise
+			allocated.Add(np.nodes[p.node].free)
+		} else {
+			allocated.Add(p.request)
+		}
+	}
+	return allocated
+}
+
+// numNodesUsed returns the # nodes with anything placed on them
+func (np *NodePool) numNodesUsed() int {
+	n := 0
+	for _, node := range np.nodes {
+		if node.numPods > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// requests returns what each master and replica of the job takes
+func (np *NodePool) requests(job *info.JobInfo) (roleRequest, roleRequest, error) {
+	masterRequest, err := np.request(job.MasterResources, job.MasterNodeType)
+	if err != nil {
+		return roleRequest{}, roleRequest{}, err
+	}
+	replicaRequest, err := np.request(job.ReplicaResources, job.ReplicaNodeType)
+	if err != nil {
+		return roleRequest{}, roleRequest{}, err
+	}
+	return masterRequest, replicaRequest, nil
+}
+
+func (np *NodePool) request(rl v1.ResourceList, nodeType string) (roleRequest, error) {
+	if len(rl) == 0 {
+		return roleRequest{numPods: 1}, nil
+	}
+	podResources, numPods, err := info.SplitRoleResources(rl)
+	if err != nil {
+		return roleRequest{}, err
+	}
+	request, err := info.NewRoleResource(podResources, np.nodeTypes, nodeType)
+	if err != nil {
+		return roleRequest{}, err
+	}
+	if request.IsEmpty() {
+		request = nil
+	}
+	return roleRequest{request: request, numPods: int(numPods)}, nil
+}
+
+func (np *NodePool) usable(node *poolNode, nodeType string) bool {
+	return nodeType == "" || node.nodeType == nodeType
+}
+
+// place puts the pods of n masters or replicas with the request on the first nodes they fit in, and
+// returns the placements and whether all of them fit. Nothing is placed if they do not.
+func (np *NodePool) place(nodeType string, rr roleRequest, n int) ([]placement, bool) {
+	request := rr.request
+	numPods := n * rr.numPods
+	placements := make([]placement, 0, numPods)
+	for len(placements) < numPods {
+		found := false
+		for i, node := range np.nodes {
+			if !np.usable(node, nodeType) || node.whole {
+				continue
+			}
+			if request == nil {
+				if node.numPods > 0 {
+					continue
+				}
+				node.whole = true
+			} else {
+				if !request.LessEqual(node.free) {
+					continue
+				}
+				node.free.Sub(request)
+			}
+			node.numPods++
+			placements = append(placements, placement{node: i, request: request})
+			found = true
+			break
+		}
+		if !found {
+			np.unplace(placements)
+			return nil, false
+		}
+	}
+	return placements, true
+}
+
+func (np *NodePool) unplace(placements []placement) {
+	for _, p := range placements {
+		node := np.nodes[p.node]
+		node.numPods--
+		if p.request == nil {
+			node.whole = false
+		} else {
+			node.free.Add(p.request)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package session_test
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func buildNodePoolSession(numNodes int) *session.Session {
+	snapshot := info.NewClusterInfo()
+	for i := 0; i < numNodes; i++ {
+		name := fmt.Sprintf("n%d", i)
+		rl := v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("8Gi"),
+		}
+		snapshot.Nodes[name] = info.NewNodeInfo(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"pinta.qed.usc.edu/type": "cpu-1"},
+			},
+			Status: v1.NodeStatus{
+				Capacity:    rl,
+				Allocatable: rl,
+			},
+		})
+	}
+	return session.OpenSessionWithSnapshot(snapshot, nil, time.Now())
+}
+
+func buildNodePoolJob(name string, replicaResources v1.ResourceList) *info.JobInfo {
+	return info.NewJobInfo(info.JobID(name), &pintav1.PintaJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: pintav1.PintaJobSpec{
+			Type: pintav1.Symmetric,
+			Replica: pintav1.RoleSpec{
+				NodeType:  "cpu-1",
+				Resources: replicaResources,
+			},
+		},
+	})
+}
+
+func TestNodePool(t *testing.T) {
+	whole := buildNodePoolJob("whole", nil)
+	half := buildNodePoolJob("half", v1.ResourceList{"node": resource.MustParse("0.5")})
+	double := buildNodePoolJob("double", v1.ResourceList{"node": resource.MustParse("2")})
+	uneven := buildNodePoolJob("uneven", v1.ResourceList{"node": resource.MustParse("1.5")})
+	cpu := buildNodePoolJob("cpu", v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")})
+
+	ssn := buildNodePoolSession(2)
+	pool := session.NewNodePool(ssn)
+	for _, test := range []struct {
+		job      *info.JobInfo
+		expected int
+	}{
+		{whole, 2},
+		{half, 4},
+		{double, 1},
+		// Roles of more than one node run as one pod per node
+		{uneven, 0},
+		{cpu, 2},
+	} {
+		if n := pool.MaxReplicas(test.job, 0); n != test.expected {
+			t.Errorf("Job %s: expected %d replicas to fit, got %d", test.job.Name, test.expected, n)
+		}
+	}
+
+	// Two nodes for one replica
+	if !pool.Clone().AllocateJob(double, 0, 1) {
+		t.Errorf("Expected a replica of two nodes to be allocated")
+	}
+	if pool.Clone().AllocateJob(double, 0, 2) {
+		t.Errorf("Expected 2 replicas of two nodes not to fit")
+	}
+
+	// A whole node and a fraction of the other one
+	if !pool.AllocateJob(whole, 0, 1) {
+		t.Fatalf("Expected a whole node to be allocated")
+	}
+	if !pool.AllocateJob(half, 0, 1) {
+		t.Fatalf("Expected half a node to be allocated")
+	}
+	if n := pool.MaxReplicas(half, 0); n != 1 {
+		t.Errorf("Expected 1 more half node replica to fit, got %d", n)
+	}
+	if n := pool.MaxReplicas(whole, 0); n != 0 {
+		t.Errorf("Expected no more whole node replicas to fit, got %d", n)
+	}
+	if pool.AllocateJob(half, 0, 2) {
+		t.Errorf("Expected 2 more half node replicas not to fit")
+	}
+	if cpu := pool.Allocated(whole).MilliCPU; cpu != 4000 {
+		t.Errorf("Expected a whole node to count all of its 4000m CPU, got %vm", cpu)
+	}
+	if cpu := pool.Allocated(half).MilliCPU; cpu != 2000 {
+		t.Errorf("Expected half a node to count 2000m CPU, got %vm", cpu)
+	}
+	if n := pool.MaxReplicas(double, 0); n != 0 {
+		t.Errorf("Expected no replica of two nodes to fit, got %d", n)
+	}
+
+	// Releasing a job frees its resources
+	pool.Release(whole)
+	if n := pool.MaxReplicas(half, 0); n != 3 {
+		t.Errorf("Expected 3 half node replicas to fit after release, got %d", n)
+	}
+}
+
+func TestNodePool_Preempt(t *testing.T) {
+	buildJob := func(name string, priority int32, preemptible bool) *info.JobInfo {
+		job := buildNodePoolJob(name, nil)
+		job.Priority = priority
+		job.Preemptible = preemptible
+		return job
+	}
+	low := buildJob("low", 0, true)
+	medium := buildJob("medium", 1, true)
+	critical := buildJob("critical", 0, false)
+	high := buildJob("high", 2, true)
+
+	ssn := buildNodePoolSession(3)
+	pool := session.NewNodePool(ssn)
+	running := []*info.JobInfo{medium, low, critical}
+	for _, job := range running {
+		if !pool.AllocateJob(job, 0, 1) {
+			t.Fatalf("Expected job %s to be allocated", job.Name)
+		}
+	}
+
+	// Only the preemptible jobs can make room, which is not enough
+	if victims := pool.Preempt(high, 0, 3, running); victims != nil {
+		t.Errorf("Expected no job to be preempted, got %d", len(victims))
+	}
+	if n := pool.MaxReplicas(high, 0); n != 0 {
+		t.Errorf("Expected the pool to be left as it was, got %d free nodes", n)
+	}
+
+	victims := pool.Preempt(high, 0, 1, running)
+	if len(victims) != 1 || victims[0] != low {
+		t.Errorf("Expected job low to be preempted, got %v", victims)
+	}
+	victims = pool.Preempt(high, 0, 1, []*info.JobInfo{medium, critical})
+	if len(victims) != 1 || victims[0] != medium {
+		t.Errorf("Expected job medium to be preempted, got %v", victims)
+	}
+	if victims := pool.Preempt(high, 0, 1, []*info.JobInfo{critical}); victims != nil {
+		t.Errorf("Expected job critical not to be preempted")
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
+	"reflect"
+	"time"
 )
 
 // Session information for the current session
@@ -19,27 +22,104 @@ type Session struct {
 	kubeClient kubernetes.Interface
 	cache      cache.Cache
 
+	// Jobs are the jobs visible to policies. A policy may remove jobs to hide them from the policies
+	// after it in the pipeline; removed jobs are still committed when the session is closed.
 	Jobs      map[info.JobID]*info.JobInfo
 	Nodes     map[string]*info.NodeInfo
 	NodeTypes map[string]*info.NodeTypeInfo
+	Queues    map[string]*info.QueueInfo
+
+	jobs                map[info.JobID]*info.JobInfo
+	jobCustomFieldsType reflect.Type
+	jobStateStore       cache.JobStateStore
+	now                 time.Time
+
+	// jobStatesUnavailable is set once the saved job states cannot be read. The states of the session
+	// are then neither saved nor flushed, so that they do not overwrite the saved ones.
+	jobStatesUnavailable bool
+
+	// explanations are the reasons policies attach for the allocations of jobs
+	explanations map[info.JobID]Explanation
+	// estimates are when policies expect jobs to start and complete
+	estimates map[info.JobID]Estimate
+
+	// dryRunReporter is set if the session is in dry-run mode
+	dryRunReporter DryRunReporter
 }
 
-func OpenSession(config *rest.Config, cache cache.Cache, policy Policy) *Session {
+// OpenSession takes a snapshot of the cache. Job custom fields are parsed for the first policy.
+func OpenSession(config *rest.Config, cache cache.Cache, policies []Policy) *Session {
 	ssn := &Session{
 		UID:        uuid.NewUUID(),
 		kubeConfig: config,
 		kubeClient: cache.Client(),
 		cache:      cache,
+		now:        time.Now(),
+
+		jobStateStore: cache.JobStateStore(),
+		explanations:  map[info.JobID]Explanation{},
+		estimates:     map[info.JobID]Estimate{},
+
+		Jobs:      map[info.JobID]*info.JobInfo{},
+		Nodes:     map[string]*info.NodeInfo{},
+		NodeTypes: map[string]*info.NodeTypeInfo{},
+		Queues:    map[string]*info.QueueInfo{},
+	}
+
+	var jobCustomFieldsType reflect.Type
+	if len(policies) > 0 {
+		jobCustomFieldsType = policies[0].JobCustomFieldsType()
+	}
+	snapshot := cache.Snapshot(jobCustomFieldsType)
+	ssn.setSnapshot(snapshot)
+	ssn.jobCustomFieldsType = jobCustomFieldsType
+
+	klog.V(3).Infof("Open Session %v with <%d> Jobs",
+		ssn.UID, len(ssn.Jobs))
+
+	return ssn
+}
+
+// OpenSessionWithSnapshot opens a session on the given cluster snapshot without a cache behind it.
+// It is used to run policies offline, e.g. in simulation, where now is the virtual time of the session.
+// Jobs in the snapshot must already have their custom fields parsed for the first policy executed. Job
+// states are kept in store, or only for the session if it is nil. Such a session has no kubernetes
+// client and must not be closed with CloseSession.
+func OpenSessionWithSnapshot(snapshot *info.ClusterInfo, store cache.JobStateStore, now time.Time) *Session {
+	if store == nil {
+		store = cache.NewMemoryJobStateStore()
+	}
+	ssn := &Session{
+		UID: uuid.NewUUID(),
+		now: now,
+
+		jobStateStore: store,
+		explanations:  map[info.JobID]Explanation{},
+		estimates:     map[info.JobID]Estimate{},
 
 		Jobs:      map[info.JobID]*info.JobInfo{},
 		Nodes:     map[string]*info.NodeInfo{},
 		NodeTypes: map[string]*info.NodeTypeInfo{},
+		Queues:    map[string]*info.QueueInfo{},
 	}
 
-	snapshot := cache.Snapshot(policy.JobCustomFieldsType())
+	ssn.setSnapshot(snapshot)
+
+	klog.V(4).Infof("Open Session %v with <%d> Jobs from snapshot",
+		ssn.UID, len(ssn.Jobs))
+
+	return ssn
+}
 
+func (ssn *Session) setSnapshot(snapshot *info.ClusterInfo) {
 	ssn.Jobs = snapshot.Jobs
 	ssn.Nodes = snapshot.Nodes
+	ssn.Queues = snapshot.Queues
+
+	ssn.jobs = make(map[info.JobID]*info.JobInfo, len(snapshot.Jobs))
+	for id, job := range snapshot.Jobs {
+		ssn.jobs[id] = job
+	}
 
 	for _, node := range ssn.Nodes {
 		nodeType, found := ssn.NodeTypes[node.Type]
@@ -49,23 +129,137 @@ func OpenSession(config *rest.Config, cache cache.Cache, policy Policy) *Session
 			ssn.NodeTypes[node.Type] = info.NewNodeTypeInfo(node)
 		}
 	}
+}
 
-	klog.V(3).Infof("Open Session %v with <%d> Jobs",
-		ssn.UID, len(ssn.Jobs))
+// ExecutePolicies executes the policies in order on the session. Job custom fields are parsed again
+// before each policy whose custom fields type differs from the one currently parsed, and job states are
+// loaded and saved around each StatefulPolicy, unless they cannot be loaded. Jobs are kept within their
+// bounds afterwards.
+func ExecutePolicies(ssn *Session, policies []Policy) {
+	for _, policy := range policies {
+		// Custom fields of sessions opened with a snapshot are parsed for the first policy already
+		jobCustomFieldsType := policy.JobCustomFieldsType()
+		if ssn.jobCustomFieldsType != nil && jobCustomFieldsType != ssn.jobCustomFieldsType {
+			for _, job := range ssn.jobs {
+				if err := job.ParseCustomFields(jobCustomFieldsType); err != nil {
+					klog.Errorf("Cannot parse custom fields for job %v: %v", job.Name, err)
+				}
+			}
+		}
+		ssn.jobCustomFieldsType = jobCustomFieldsType
 
-	return ssn
+		statefulPolicy, stateful := policy.(StatefulPolicy)
+		var jobs []*info.JobInfo
+		if stateful {
+			jobs = ssn.loadJobStates(statefulPolicy)
+		}
+
+		klog.V(4).Infof("Execute policy %s in session %v", policy.Name(), ssn.UID)
+		policy.Execute(ssn)
+
+		if stateful && !ssn.jobStatesUnavailable {
+			ssn.saveJobStates(statefulPolicy, jobs)
+		}
+	}
+
+	for _, job := range ssn.jobs {
+		ssn.enforceBounds(job)
+	}
+}
+
+// enforceBounds stops a job allocated fewer masters or replicas than its minimums, and takes away the
+// masters and replicas beyond its maximums
+func (ssn *Session) enforceBounds(job *info.JobInfo) {
+	if job.InBounds(job.NumMasters, job.NumReplicas) {
+		return
+	}
+	if job.NumMasters < job.MinMasters || job.NumReplicas < job.MinReplicas {
+		klog.Warningf("Job <%s/%s> is allocated %d masters and %d replicas, below its minimums, stopping it",
+			job.Namespace, job.Name, job.NumMasters, job.NumReplicas)
+		ssn.Explainf(job, ReasonBelowMinGangSize, "below min gang size: %d masters and %d replicas fit, %d and %d needed",
+			job.NumMasters, job.NumReplicas, job.MinMasters, job.MinReplicas)
+		job.NumMasters = 0
+		job.NumReplicas = 0
+		return
+	}
+	klog.Warningf("Job <%s/%s> is allocated %d masters and %d replicas, above its maximums",
+		job.Namespace, job.Name, job.NumMasters, job.NumReplicas)
+	job.NumMasters = job.FitMasters(job.NumMasters)
+	job.NumReplicas = job.FitReplicas(job.NumReplicas)
 }
 
+// loadJobStates sets the states of the policy to the jobs visible to it, and returns these jobs. Jobs
+// get new states if the saved ones cannot be read.
+func (ssn *Session) loadJobStates(policy StatefulPolicy) []*info.JobInfo {
+	jobStateType := policy.JobStateType()
+	jobs := make([]*info.JobInfo, 0, len(ssn.Jobs))
+	for _, job := range ssn.Jobs {
+		job.State = reflect.New(jobStateType.Elem()).Interface()
+		jobs = append(jobs, job)
+		if ssn.jobStatesUnavailable {
+			continue
+		}
+		state, found, err := ssn.jobStateStore.Get(job.UID, policy.Name())
+		if err != nil {
+			klog.Errorf("Cannot load job states, they are not saved in session %v: %v", ssn.UID, err)
+			ssn.jobStatesUnavailable = true
+			continue
+		}
+		if found {
+			if err := yaml.Unmarshal([]byte(state), job.State); err != nil {
+				klog.Errorf("Cannot parse state of policy %s for job %v: %v", policy.Name(), job.Name, err)
+				job.State = reflect.New(jobStateType.Elem()).Interface()
+			}
+		}
+	}
+	return jobs
+}
+
+func (ssn *Session) saveJobStates(policy StatefulPolicy, jobs []*info.JobInfo) {
+	for _, job := range jobs {
+		state, err := yaml.Marshal(job.State)
+		if err != nil {
+			klog.Errorf("Cannot save state of policy %s for job %v: %v", policy.Name(), job.Name, err)
+			continue
+		}
+		ssn.jobStateStore.Set(job.UID, policy.Name(), string(state))
+	}
+}
+
+// CloseSession commits the allocations of the jobs, or reports them if the session is in dry-run mode
 func CloseSession(ssn *Session) {
-	ju := newJobUpdater(ssn)
-	ju.UpdateAll()
+	if ssn.dryRunReporter != nil {
+		ssn.dryRunReporter.Report(ssn.dryRunResult())
+	} else {
+		ju := newJobUpdater(ssn)
+		ju.UpdateAll()
+	}
+
+	jobIDs := make([]info.JobID, 0, len(ssn.jobs))
+	for id := range ssn.jobs {
+		jobIDs = append(jobIDs, id)
+	}
+	if ssn.jobStatesUnavailable {
+		klog.Warningf("Job states are not flushed in session %v, they could not be loaded", ssn.UID)
+	} else if err := ssn.jobStateStore.Flush(jobIDs); err != nil {
+		klog.Errorf("Failed to flush job states: %v", err)
+	}
 
 	ssn.Jobs = nil
 	ssn.Nodes = nil
+	ssn.Queues = nil
+	ssn.jobs = nil
+	ssn.explanations = nil
+	ssn.estimates = nil
 
 	klog.V(3).Infof("Close Session %v", ssn.UID)
 }
 
+// Now returns the time the session is opened at
+func (ssn Session) Now() time.Time {
+	return ssn.now
+}
+
 // KubeConfig returns the configuration to access kubernetes API
 func (ssn Session) KubeConfig() *rest.Config {
 	return ssn.kubeConfig
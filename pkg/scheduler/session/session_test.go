@@ -0,0 +1,356 @@
+package session_test
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/nop"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSession_MemoryCache(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	policy, err := nop.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	ssn := session.OpenSession(nil, mc, []session.Policy{policy})
+	if len(ssn.Jobs) != 2 || len(ssn.Nodes) != 2 || len(ssn.NodeTypes) != 1 {
+		t.Errorf("Wrong snapshot, expected 2 jobs, 2 nodes and 1 node type, got %d jobs, %d nodes and %d node types",
+			len(ssn.Jobs), len(ssn.Nodes), len(ssn.NodeTypes))
+	}
+	policy.Execute(ssn)
+	session.CloseSession(ssn)
+
+	tests := []struct {
+		id       info.JobID
+		updated  bool
+		expected pintav1.PintaJobStatus
+	}{
+		{
+			id:      "default/j1",
+			updated: true,
+			expected: pintav1.PintaJobStatus{
+				State:       pintav1.Idle,
+				NumMasters:  1,
+				NumReplicas: 1,
+				Reason:      session.ReasonScheduled,
+				Message:     "allocated 1 masters and 1 replicas",
+			},
+		},
+		{
+			id:      "default/j2",
+			updated: true,
+			expected: pintav1.PintaJobStatus{
+				State:   pintav1.Idle,
+				Reason:  session.ReasonNotScheduled,
+				Message: "no masters or replicas requested",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		status, updated := mc.StatusSink.LastStatus(test.id)
+		if updated != test.updated {
+			t.Errorf("job %v: expected updated %v, got %v", test.id, test.updated, updated)
+			continue
+		}
+		status.LastTransitionTime = test.expected.LastTransitionTime
+		if status != test.expected {
+			t.Errorf("job %v: \n expected %+v, \n got %+v \n", test.id, test.expected, status)
+		}
+	}
+	if mc.StatusSink.Len() != 2 {
+		t.Errorf("Expected 2 status updates, got %d", mc.StatusSink.Len())
+	}
+	if len(mc.Events) != 2 {
+		t.Errorf("Expected 2 events, got %+v", mc.Events)
+	}
+
+	// The committed status is visible to the next session, so nothing changes
+	mc.StatusSink.Reset()
+	ssn = session.OpenSession(nil, mc, []session.Policy{policy})
+	policy.Execute(ssn)
+	session.CloseSession(ssn)
+	if mc.StatusSink.Len() != 0 {
+		t.Errorf("Expected no status update, got %d", mc.StatusSink.Len())
+	}
+	if len(mc.Events) != 2 {
+		t.Errorf("Expected no more events, got %+v", mc.Events[2:])
+	}
+}
+
+type dryRunRecorder struct {
+	results []*session.DryRunResult
+}
+
+func (r *dryRunRecorder) Report(result *session.DryRunResult) {
+	r.results = append(r.results, result)
+}
+
+func TestCloseSession_DryRun(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	policy, err := nop.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	recorder := &dryRunRecorder{}
+	ssn := session.OpenSession(nil, mc, []session.Policy{policy})
+	ssn.DryRun(recorder)
+	session.ExecutePolicies(ssn, []session.Policy{policy})
+	session.CloseSession(ssn)
+
+	if mc.StatusSink.Len() != 0 {
+		t.Errorf("Expected no status update in dry-run mode, got %d", mc.StatusSink.Len())
+	}
+	if len(recorder.results) != 1 {
+		t.Fatalf("Expected 1 dry-run result, got %d", len(recorder.results))
+	}
+	expected := []session.JobDiff{
+		{
+			Job:                 "default/j1",
+			State:               pintav1.Idle,
+			ProposedNumMasters:  1,
+			ProposedNumReplicas: 1,
+			Reason:              session.ReasonScheduled,
+			Message:             "allocated 1 masters and 1 replicas",
+		},
+	}
+	result := recorder.results[0]
+	if !reflect.DeepEqual(result.Changes, expected) || result.NumUnchanged != 1 {
+		t.Errorf("Expected changes %+v and 1 unchanged job, got %+v and %d", expected, result.Changes, result.NumUnchanged)
+	}
+}
+
+type hideJobsPolicy struct{}
+
+type hideJobsCustomFields struct {
+	NumReplicas int32 `yaml:"numReplicas"`
+}
+
+func (p *hideJobsPolicy) Name() string { return "hide-jobs" }
+
+func (p *hideJobsPolicy) Initialize() {}
+
+func (p *hideJobsPolicy) JobCustomFieldsType() reflect.Type {
+	return reflect.TypeOf((*hideJobsCustomFields)(nil))
+}
+
+func (p *hideJobsPolicy) Execute(ssn *session.Session) {
+	for id, job := range ssn.Jobs {
+		if _, ok := job.CustomFields.(*hideJobsCustomFields); !ok {
+			panic("custom fields are not parsed for hide-jobs")
+		}
+		delete(ssn.Jobs, id)
+	}
+}
+
+func (p *hideJobsPolicy) UnInitialize() {}
+
+func TestExecutePolicies(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	policy, err := nop.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	policies := []session.Policy{policy, &hideJobsPolicy{}, policy}
+	ssn := session.OpenSession(nil, mc, policies)
+	session.ExecutePolicies(ssn, policies)
+	if len(ssn.Jobs) != 0 {
+		t.Errorf("Expected all jobs to be hidden, got %d jobs", len(ssn.Jobs))
+	}
+	session.CloseSession(ssn)
+
+	// Jobs hidden from later policies are still committed
+	status, updated := mc.StatusSink.LastStatus("default/j1")
+	if !updated || status.NumMasters != 1 || status.NumReplicas != 1 {
+		t.Errorf("Expected job default/j1 to be committed with 1 master and 1 replica, got %+v", status)
+	}
+}
+
+type countSessionsPolicy struct {
+	hideJobsPolicy
+}
+
+type countSessionsState struct {
+	NumSessions int `yaml:"numSessions"`
+}
+
+func (p *countSessionsPolicy) Name() string { return "count-sessions" }
+
+func (p *countSessionsPolicy) JobStateType() reflect.Type {
+	return reflect.TypeOf((*countSessionsState)(nil))
+}
+
+func (p *countSessionsPolicy) Execute(ssn *session.Session) {
+	for _, job := range ssn.Jobs {
+		job.State.(*countSessionsState).NumSessions++
+	}
+}
+
+func TestExecutePolicies_JobStates(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	policies := []session.Policy{&countSessionsPolicy{}}
+	for i := 0; i < 3; i++ {
+		ssn := session.OpenSession(nil, mc, policies)
+		session.ExecutePolicies(ssn, policies)
+		session.CloseSession(ssn)
+	}
+
+	state, found, _ := mc.JobStates.Get("default/j1", "count-sessions")
+	if !found || state != "numSessions: 3\n" {
+		t.Errorf("Expected the state of job default/j1 to survive sessions, got %q", state)
+	}
+
+	// States of jobs that are gone are dropped
+	mc.DeleteJob(&pintav1.PintaJob{ObjectMeta: metav1.ObjectMeta{Name: "j1", Namespace: "default"}})
+	ssn := session.OpenSession(nil, mc, policies)
+	session.ExecutePolicies(ssn, policies)
+	session.CloseSession(ssn)
+	if _, found, _ := mc.JobStates.Get("default/j1", "count-sessions"); found {
+		t.Errorf("Expected the state of deleted job default/j1 to be dropped")
+	}
+	if state, _, _ := mc.JobStates.Get("default/j2", "count-sessions"); state != "numSessions: 4\n" {
+		t.Errorf("Expected job default/j2 to have been seen in 4 sessions, got %q", state)
+	}
+}
+
+// unavailableJobStateStore cannot read the saved states, and records the states set to it
+type unavailableJobStateStore struct {
+	set map[info.JobID]string
+}
+
+func (s *unavailableJobStateStore) Get(jobID info.JobID, policy string) (string, bool, error) {
+	return "", false, fmt.Errorf("connection refused")
+}
+
+func (s *unavailableJobStateStore) Set(jobID info.JobID, policy string, state string) {
+	s.set[jobID] = state
+}
+
+func (s *unavailableJobStateStore) Flush(jobIDs []info.JobID) error {
+	return nil
+}
+
+func TestExecutePolicies_JobStatesUnavailable(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	// New states must not overwrite the saved ones that could not be read
+	store := &unavailableJobStateStore{set: make(map[info.JobID]string)}
+	policy := &countSessionsPolicy{}
+	ssn := session.OpenSessionWithSnapshot(mc.Snapshot(policy.JobCustomFieldsType()), store, time.Now())
+	session.ExecutePolicies(ssn, []session.Policy{policy})
+	if len(store.set) != 0 {
+		t.Errorf("Expected no job state to be saved, got %v", store.set)
+	}
+}
+
+type estimateStartPolicy struct {
+	hideJobsPolicy
+	start time.Time
+}
+
+func (p *estimateStartPolicy) Name() string { return "estimate-start" }
+
+func (p *estimateStartPolicy) Execute(ssn *session.Session) {
+	for _, job := range ssn.Jobs {
+		ssn.EstimateStart(job, p.start)
+	}
+}
+
+func TestCloseSession_Estimates(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		start    time.Time
+		expected time.Time
+	}{
+		{start: start, expected: start},
+		// Estimates that move by less than a minute are not updated
+		{start: start.Add(30 * time.Second), expected: start},
+		{start: start.Add(2 * time.Minute), expected: start.Add(2 * time.Minute)},
+	}
+	for i, test := range tests {
+		policies := []session.Policy{&estimateStartPolicy{start: test.start}}
+		ssn := session.OpenSession(nil, mc, policies)
+		session.ExecutePolicies(ssn, policies)
+		session.CloseSession(ssn)
+
+		status, _ := mc.StatusSink.LastStatus("default/j2")
+		if status.EstimatedStartTime == nil || !status.EstimatedStartTime.Time.Equal(test.expected) {
+			t.Errorf("Session %d: expected estimated start time %v, got %v", i, test.expected, status.EstimatedStartTime)
+		}
+	}
+}
+
+type explainPolicy struct {
+	hideJobsPolicy
+	reason  string
+	message string
+}
+
+func (p *explainPolicy) Name() string { return "explain" }
+
+func (p *explainPolicy) Execute(ssn *session.Session) {
+	for _, job := range ssn.Jobs {
+		ssn.Explainf(job, p.reason, "%s", p.message)
+	}
+}
+
+func TestCloseSession_Explanations(t *testing.T) {
+	mc, err := cache.NewMemoryCacheFromFixtures("testdata/cluster.yaml", "testdata/jobs.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	tests := []struct {
+		reason   string
+		message  string
+		expected string
+	}{
+		{reason: session.ReasonQueued, message: "queue position 2", expected: "queue position 2"},
+		// Messages that change without the reason are not updated
+		{reason: session.ReasonQueued, message: "queue position 1", expected: "queue position 2"},
+		{reason: session.ReasonInsufficientResources, message: "cluster exhausted", expected: "cluster exhausted"},
+	}
+	for i, test := range tests {
+		policies := []session.Policy{&explainPolicy{reason: test.reason, message: test.message}}
+		ssn := session.OpenSession(nil, mc, policies)
+		session.ExecutePolicies(ssn, policies)
+		session.CloseSession(ssn)
+
+		status, _ := mc.StatusSink.LastStatus("default/j2")
+		if status.Reason != test.reason || status.Message != test.expected {
+			t.Errorf("Session %d: expected reason %s and message %q, got %s and %q",
+				i, test.reason, test.expected, status.Reason, status.Message)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package sessiontest builds the jobs and snapshots policies are tested on
+package sessiontest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+)
+
+// JobOption changes a job built by BuildJob before its custom fields are parsed
+type JobOption func(ji *info.JobInfo)
+
+// BuildJob returns an idle symmetric job of the default namespace, with the custom fields parsed as
+// customFieldsType if it is not nil. The ID of the job is its name, or <namespace>/<name> in other
+// namespaces.
+func BuildJob(name string, customFieldsType reflect.Type, options ...JobOption) *info.JobInfo {
+	job := &pintav1.PintaJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: pintav1.PintaJobSpec{
+			Type: pintav1.Symmetric,
+		},
+		Status: []pintav1.PintaJobStatus{
+			{
+				State: pintav1.Idle,
+			},
+		},
+	}
+	ji := info.NewJobInfo(info.JobID(name), job)
+	for _, option := range options {
+		option(ji)
+	}
+	if customFieldsType != nil {
+		if err := ji.ParseCustomFields(customFieldsType); err != nil {
+			panic(err)
+		}
+	}
+	return ji
+}
+
+// InNamespace puts the job in the namespace
+func InNamespace(namespace string) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.Namespace = namespace
+		ji.Job.Namespace = namespace
+		ji.UID = info.JobID(namespace + "/" + ji.Name)
+	}
+}
+
+// CreatedAt sets the creation time of the job
+func CreatedAt(timestamp time.Time) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.CreationTimestamp = metav1.NewTime(timestamp)
+		ji.Job.CreationTimestamp = ji.CreationTimestamp
+	}
+}
+
+// WithCustomFields sets the custom fields annotation of the job
+func WithCustomFields(customFields string) JobOption {
+	return func(ji *info.JobInfo) {
+		if ji.Job.Annotations == nil {
+			ji.Job.Annotations = map[string]string{}
+		}
+		ji.Job.Annotations["pinta.qed.usc.edu/custom-fields"] = customFields
+	}
+}
+
+// WithLabels sets the labels of the job
+func WithLabels(labels map[string]string) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.Job.Labels = labels
+	}
+}
+
+// InQueue submits the job to the queue
+func InQueue(queue string) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.Queue = queue
+		ji.Job.Spec.Queue = queue
+	}
+}
+
+// WithReplicaResources sets the resources of each replica of the job
+func WithReplicaResources(rl v1.ResourceList) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.ReplicaResources = rl
+		ji.Job.Spec.Replica.Resources = rl
+	}
+}
+
+// WithReplicaNodeType pins the replicas of the job to the node type
+func WithReplicaNodeType(nodeType string) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.ReplicaNodeType = nodeType
+		ji.Job.Spec.Replica.NodeType = nodeType
+	}
+}
+
+// WithReplicaBounds sets the bounds of the # replicas of the job
+func WithReplicaBounds(minReplicas, maxReplicas int32) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.MinReplicas = minReplicas
+		ji.MaxReplicas = maxReplicas
+	}
+}
+
+// WithPriority sets the priority of the job, and whether it may be preempted
+func WithPriority(priority int32, preemptible bool) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.Priority = priority
+		ji.Preemptible = preemptible
+	}
+}
+
+// Running sets the # replicas the job currently runs with
+func Running(numReplicas int32) JobOption {
+	return func(ji *info.JobInfo) {
+		ji.NumReplicas = numReplicas
+	}
+}
+
+// BuildNodes returns nodes of the type with the allocatable resources, named n0, n1, ... if the type is
+// empty and <type>-0, <type>-1, ... otherwise. Nodes without resources take one role each.
+func BuildNodes(nodeType string, numNodes int, allocatable v1.ResourceList) []*info.NodeInfo {
+	nodes := make([]*info.NodeInfo, 0, numNodes)
+	for i := 0; i < numNodes; i++ {
+		node := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("n%d", i)},
+			Status: v1.NodeStatus{
+				Capacity:    allocatable,
+				Allocatable: allocatable,
+			},
+		}
+		if nodeType != "" {
+			node.Name = fmt.Sprintf("%s-%d", nodeType, i)
+			node.Labels = map[string]string{"pinta.qed.usc.edu/type": nodeType}
+		}
+		nodes = append(nodes, info.NewNodeInfo(node))
+	}
+	return nodes
+}
+
+// BuildNodesByType returns nodes without resources of each type, in the # given for the type
+func BuildNodesByType(numNodesByType map[string]int) []*info.NodeInfo {
+	nodeTypes := make([]string, 0, len(numNodesByType))
+	for nodeType := range numNodesByType {
+		nodeTypes = append(nodeTypes, nodeType)
+	}
+	sort.Strings(nodeTypes)
+
+	var nodes []*info.NodeInfo
+	for _, nodeType := range nodeTypes {
+		nodes = append(nodes, BuildNodes(nodeType, numNodesByType[nodeType], nil)...)
+	}
+	return nodes
+}
+
+// BuildSnapshot returns a snapshot of the nodes and jobs
+func BuildSnapshot(nodes []*info.NodeInfo, jobs ...*info.JobInfo) *info.ClusterInfo {
+	snapshot := info.NewClusterInfo()
+	for _, node := range nodes {
+		snapshot.Nodes[node.Name] = node
+	}
+	for _, job := range jobs {
+		snapshot.Jobs[job.UID] = job
+	}
+	return snapshot
+}
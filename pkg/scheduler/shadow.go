@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"k8s.io/klog"
+
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	"github.com/qed-usc/pinta-scheduler/pkg/metrics"
+	pintacache "github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+)
+
+// shadowPolicy is executed each cycle on its own copy of the snapshot the session started from, and
+// compared with the policies pipeline without committing anything
+type shadowPolicy struct {
+	policy session.Policy
+	// jobStates are the states the policy keeps for each job, apart from the ones of the pipeline
+	jobStates *pintacache.MemoryJobStateStore
+}
+
+func newShadowPolicy(policy session.Policy) *shadowPolicy {
+	return &shadowPolicy{
+		policy:    policy,
+		jobStates: pintacache.NewMemoryJobStateStore(),
+	}
+}
+
+// evaluate executes the policy on the snapshot, and records where its allocations differ from the ones
+// of the active session. It returns the differences and the summary of the allocations of the policy.
+func (sp *shadowPolicy) evaluate(
+	active *session.Session,
+	activeSummary session.Summary,
+	snapshot *info.ClusterInfo,
+) ([]session.JobDiff, session.Summary) {
+	name := sp.policy.Name()
+	jobCustomFieldsType := sp.policy.JobCustomFieldsType()
+	jobIDs := make([]info.JobID, 0, len(snapshot.Jobs))
+	for id, job := range snapshot.Jobs {
+		if err := job.ParseCustomFields(jobCustomFieldsType); err != nil {
+			klog.Errorf("Cannot parse custom fields of shadow policy %s for job %v: %v", name, job.Name, err)
+		}
+		jobIDs = append(jobIDs, id)
+	}
+
+	ssn := session.OpenSessionWithSnapshot(snapshot, sp.jobStates, active.Now())
+	session.ExecutePolicies(ssn, []session.Policy{sp.policy})
+	if err := sp.jobStates.Flush(jobIDs); err != nil {
+		klog.Errorf("Failed to flush job states of shadow policy %s: %v", name, err)
+	}
+
+	diffs := active.Diff(ssn)
+	for _, diff := range diffs {
+		klog.V(3).Infof("Shadow policy %s: job <%s> would get %d masters and %d replicas instead of %d and %d",
+			name, diff.Job, diff.ProposedNumMasters, diff.ProposedNumReplicas, diff.NumMasters, diff.NumReplicas)
+	}
+	summary := ssn.Summarize()
+	klog.V(3).Infof("Shadow policy %s allocates %d jobs differently, using %d nodes instead of %d and starving %d jobs instead of %d",
+		name, len(diffs), summary.NumNodesUsed, activeSummary.NumNodesUsed, summary.NumJobsStarved, activeSummary.NumJobsStarved)
+	metrics.UpdateShadowPolicy(name, summary.NumNodesUsed, summary.NumJobsStarved, len(diffs))
+	return diffs, summary
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	pintacache "github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/equi"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/nop"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShadowPolicy_Evaluate(t *testing.T) {
+	mc := pintacache.NewMemoryCache()
+	for i := 0; i < 2; i++ {
+		mc.AddNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("n%d", i)},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		})
+	}
+	for name, numReplicas := range map[string]int{"j1": 1, "j2": 0} {
+		mc.AddJob(&pintav1.PintaJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: map[string]string{"pinta.qed.usc.edu/custom-fields": fmt.Sprintf("{numReplicas: %d}", numReplicas)},
+			},
+			Spec:   pintav1.PintaJobSpec{Type: pintav1.Symmetric},
+			Status: []pintav1.PintaJobStatus{{State: pintav1.Idle}},
+		})
+	}
+
+	active, err := nop.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	shadow, err := equi.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	ssn := session.OpenSession(nil, mc, []session.Policy{active})
+	snapshot := ssn.Snapshot()
+	session.ExecutePolicies(ssn, []session.Policy{active})
+	activeSummary := ssn.Summarize()
+	diffs, summary := newShadowPolicy(shadow).evaluate(ssn, activeSummary, snapshot)
+	session.CloseSession(ssn)
+
+	expectedDiffs := []session.JobDiff{
+		{
+			Job:                 "default/j2",
+			State:               pintav1.Idle,
+			ProposedNumReplicas: 1,
+			Reason:              session.ReasonScheduled,
+			Message:             "equal share of 1 replicas",
+		},
+	}
+	if !reflect.DeepEqual(diffs, expectedDiffs) {
+		t.Errorf("Expected differences %+v, got %+v", expectedDiffs, diffs)
+	}
+	if expected := (session.Summary{NumNodesUsed: 1, NumJobsStarved: 1}); activeSummary != expected {
+		t.Errorf("Expected summary of the active policy %+v, got %+v", expected, activeSummary)
+	}
+	if expected := (session.Summary{NumNodesUsed: 2}); summary != expected {
+		t.Errorf("Expected summary of the shadow policy %+v, got %+v", expected, summary)
+	}
+
+	// Only the allocations of the active policy are committed
+	if status, _ := mc.StatusSink.LastStatus("default/j2"); status.NumReplicas != 0 || status.Reason != session.ReasonNotScheduled {
+		t.Errorf("Expected job default/j2 to keep no replicas, got %+v", status)
+	}
+}
@@ -31,7 +31,7 @@ var defaultSchedulerConf = `
 policy: "nop"
 `
 
-func loadSchedulerConf(confStr string) (session.Policy, *conf.Configuration, error) {
+func loadSchedulerConf(confStr string) ([]session.Policy, []conf.Configuration, error) {
 	schedulerConf := &conf.SchedulerConfiguration{}
 
 	buf := make([]byte, len(confStr))
@@ -41,13 +41,36 @@ func loadSchedulerConf(confStr string) (session.Policy, *conf.Configuration, err
 		return nil, nil, err
 	}
 
-	policyName := schedulerConf.Policy
-	policy, found := session.GetPolicy(strings.TrimSpace(policyName))
-	if !found {
-		return nil, nil, fmt.Errorf("failed to found Policy %s", policyName)
+	policyOptions := schedulerConf.Policies
+	if len(strings.TrimSpace(schedulerConf.Policy)) != 0 {
+		if len(policyOptions) != 0 {
+			return nil, nil, fmt.Errorf("policy and policies cannot be specified together")
+		}
+		policyOptions = []conf.PolicyOption{
+			{
+				Name:          schedulerConf.Policy,
+				Configuration: schedulerConf.Configuration,
+			},
+		}
 	}
+	if len(policyOptions) == 0 {
+		return nil, nil, fmt.Errorf("no policy specified")
+	}
+
+	return session.BuildPolicies(policyOptions)
+}
 
-	return policy, &schedulerConf.Configuration, nil
+// loadShadowPolicies builds the shadow policies of the scheduler configuration
+func loadShadowPolicies(confStr string) ([]session.Policy, error) {
+	schedulerConf := &conf.SchedulerConfiguration{}
+	if err := yaml.Unmarshal([]byte(confStr), schedulerConf); err != nil {
+		return nil, err
+	}
+	policies, _, err := session.BuildPolicies(schedulerConf.ShadowPolicies)
+	if err != nil {
+		return nil, fmt.Errorf("shadow policies: %v", err)
+	}
+	return policies, nil
 }
 
 func readSchedulerConf(confPath string) (string, error) {
@@ -3,7 +3,9 @@ package scheduler
 import (
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/conf"
 	_ "github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/hell"
 	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/nop"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
 	"reflect"
 	"testing"
 )
@@ -16,24 +18,151 @@ configuration:
     k: 1.2
     b: true
 `
-	expectedPolicy := &nop.Policy{}
-	expectedConfiguration := &conf.Configuration{
-		Arguments: map[string]string{
-			"k": "1.2",
-			"b": "true",
+	expectedPolicies := []session.Policy{&nop.Policy{}}
+	expectedConfigurations := []conf.Configuration{
+		{
+			Arguments: map[string]string{
+				"k": "1.2",
+				"b": "true",
+			},
 		},
 	}
 
-	policy, configuration, err := loadSchedulerConf(schedulerConf)
+	policies, configurations, err := loadSchedulerConf(schedulerConf)
 	if err != nil {
 		t.Errorf("Failed to load scheduler configuration: %v", err)
 	}
-	if !reflect.DeepEqual(policy, expectedPolicy) {
+	if !reflect.DeepEqual(policies, expectedPolicies) {
 		t.Errorf("Failed to set default settings for policies, expected: %+v, got %+v",
-			expectedPolicy, policy)
+			expectedPolicies, policies)
 	}
-	if !reflect.DeepEqual(configuration, expectedConfiguration) {
+	if !reflect.DeepEqual(configurations, expectedConfigurations) {
 		t.Errorf("Wrong configuration, expected: %+v, got %+v",
-			expectedConfiguration, configuration)
+			expectedConfigurations, configurations)
+	}
+}
+
+func TestLoadSchedulerConf_Pipeline(t *testing.T) {
+	schedulerConf := `
+policies:
+- name: hell
+  configuration:
+    arguments:
+      k: 1.2
+- name: nop
+`
+	expectedPolicies := []session.Policy{&hell.Policy{}, &nop.Policy{}}
+	expectedConfigurations := []conf.Configuration{
+		{
+			Arguments: map[string]string{
+				"k": "1.2",
+			},
+		},
+		{},
+	}
+
+	policies, configurations, err := loadSchedulerConf(schedulerConf)
+	if err != nil {
+		t.Errorf("Failed to load scheduler configuration: %v", err)
+	}
+	if !reflect.DeepEqual(policies, expectedPolicies) {
+		t.Errorf("Wrong policies, expected: %+v, got %+v",
+			expectedPolicies, policies)
+	}
+	if !reflect.DeepEqual(configurations, expectedConfigurations) {
+		t.Errorf("Wrong configuration, expected: %+v, got %+v",
+			expectedConfigurations, configurations)
+	}
+
+	invalidConfs := []string{
+		``,
+		`policy: nop
+policies:
+- name: nop
+`,
+		`policies:
+- name: unknown
+`,
+	}
+	for _, invalidConf := range invalidConfs {
+		if _, _, err := loadSchedulerConf(invalidConf); err == nil {
+			t.Errorf("Expected error loading scheduler configuration %q", invalidConf)
+		}
+	}
+}
+
+func TestLoadSchedulerConf_Arguments(t *testing.T) {
+	validConfs := []string{
+		`policy: equi
+configuration:
+  arguments:
+    rebalanceThreshold: 2
+`,
+		`policy: hell
+configuration:
+  arguments:
+    defaultThroughput: 10, 20, 30
+`,
+	}
+	for _, validConf := range validConfs {
+		if _, _, err := loadSchedulerConf(validConf); err != nil {
+			t.Errorf("Failed to load scheduler configuration %q: %v", validConf, err)
+		}
+	}
+
+	invalidConfs := []string{
+		`policy: equi
+configuration:
+  arguments:
+    rebalanceThreshold: one
+`,
+		`policy: equi
+configuration:
+  arguments:
+    rebalanceThreshold: -1
+`,
+		`policies:
+- name: nop
+- name: hell
+  configuration:
+    arguments:
+      defaultThroughput: 10,,30
+`,
+		`policy: hell
+configuration:
+  arguments:
+    defaultThroughput: 10,0
+`,
+	}
+	for _, invalidConf := range invalidConfs {
+		if _, _, err := loadSchedulerConf(invalidConf); err == nil {
+			t.Errorf("Expected error loading scheduler configuration %q", invalidConf)
+		}
+	}
+}
+
+func TestLoadShadowPolicies(t *testing.T) {
+	schedulerConf := `
+policy: nop
+shadowPolicies:
+- name: hell
+- name: equi
+  configuration:
+    arguments:
+      rebalanceThreshold: 2
+`
+	policies, err := loadShadowPolicies(schedulerConf)
+	if err != nil {
+		t.Fatalf("Failed to load shadow policies: %v", err)
+	}
+	if len(policies) != 2 || policies[0].Name() != "hell" || policies[1].Name() != "equi" {
+		t.Errorf("Expected shadow policies hell and equi, got %+v", policies)
+	}
+
+	if policies, err := loadShadowPolicies("policy: nop\n"); err != nil || len(policies) != 0 {
+		t.Errorf("Expected no shadow policies, got %+v and error %v", policies, err)
+	}
+	if _, err := loadShadowPolicies("policy: nop\nshadowPolicies:\n- name: unknown\n"); err == nil {
+		t.Errorf("Expected error loading unknown shadow policy")
 	}
 }
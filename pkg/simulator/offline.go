@@ -0,0 +1,393 @@
+package simulator
+
+import (
+	"fmt"
+	"github.com/qed-usc/pinta-scheduler/cmd/simulator/options"
+	"github.com/qed-usc/pinta-scheduler/pkg/apis/info"
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	simulationapi "github.com/qed-usc/pinta-scheduler/pkg/apis/simulation"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/cache"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"math"
+	"os"
+	k8syaml "sigs.k8s.io/yaml"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobCustomFields are the custom fields the offline simulator reads to model the progress of a job.
+// Throughput is given in examples per second for 1, 2, ... replicas.
+type JobCustomFields struct {
+	BatchSize  int       `yaml:"batchSize"`
+	Iterations int       `yaml:"iterations"`
+	Throughput []float64 `yaml:"throughput"`
+}
+
+// OfflineSimulator runs a policy against a synthetic cluster with a virtual clock. Jobs arrive at the
+// times given in the SimulatorSpec and progress according to the throughput of their allocation.
+type OfflineSimulator struct {
+	spec    *simulationapi.SimulatorSpec
+	policy  session.Policy
+	period  float64
+	fileOut string
+
+	baseTimestamp time.Time
+	now           float64
+	nodes         map[string]*info.NodeInfo
+	jobStates     *cache.MemoryJobStateStore
+}
+
+type simulatedJob struct {
+	info         *info.JobInfo
+	customFields *JobCustomFields
+
+	arrival             float64
+	start               float64
+	finish              float64
+	completedIterations float64
+}
+
+func NewOfflineSimulator(opt *options.Option) (*OfflineSimulator, error) {
+	var spec simulationapi.SimulatorSpec
+	if err := readSpec(opt.FileIn, &spec); err != nil {
+		return nil, err
+	}
+	var cluster simulationapi.ClusterSpec
+	if err := readSpec(opt.ClusterFile, &cluster); err != nil {
+		return nil, err
+	}
+
+	builder, found := session.GetPolicyBuilder(strings.TrimSpace(opt.Policy))
+	if !found {
+		return nil, fmt.Errorf("failed to found Policy %s", opt.Policy)
+	}
+	policy, err := builder(opt.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for Policy %s: %v", opt.Policy, err)
+	}
+
+	simulator := newOfflineSimulator(&spec, &cluster, policy, opt.Period)
+	simulator.fileOut = opt.FileOut
+	return simulator, nil
+}
+
+func newOfflineSimulator(
+	spec *simulationapi.SimulatorSpec,
+	cluster *simulationapi.ClusterSpec,
+	policy session.Policy,
+	period float64,
+) *OfflineSimulator {
+	return &OfflineSimulator{
+		spec:          spec,
+		policy:        policy,
+		period:        period,
+		baseTimestamp: time.Unix(0, 0).UTC(),
+		nodes:         buildNodes(cluster),
+		jobStates:     cache.NewMemoryJobStateStore(),
+	}
+}
+
+func readSpec(path string, spec interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return k8syaml.Unmarshal(content, spec)
+}
+
+func buildNodes(cluster *simulationapi.ClusterSpec) map[string]*info.NodeInfo {
+	nodes := make(map[string]*info.NodeInfo)
+	for _, group := range cluster.Nodes {
+		for i := 0; i < group.Count; i++ {
+			node := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("simulated-node-%d", len(nodes)),
+					Labels: map[string]string{
+						"pinta.qed.usc.edu/type": group.Type,
+					},
+				},
+				Status: v1.NodeStatus{
+					Capacity:    group.Resources.DeepCopy(),
+					Allocatable: group.Resources.DeepCopy(),
+					Conditions: []v1.NodeCondition{
+						{
+							Type:   v1.NodeReady,
+							Status: v1.ConditionTrue,
+						},
+					},
+				},
+			}
+			nodes[node.Name] = info.NewNodeInfo(node)
+		}
+	}
+	return nodes
+}
+
+// Run simulates all jobs and writes the result to the output file, or to stdout if none is given.
+func (s *OfflineSimulator) Run() error {
+	result, err := s.Simulate()
+	if err != nil {
+		return err
+	}
+
+	out, err := k8syaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	if s.fileOut == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(s.fileOut, out, 0644)
+}
+
+// Simulate runs the policy until every job completes. The policy is executed whenever a job arrives or
+// completes, and additionally every period seconds if period is positive. The simulation fails once no
+// job runs or arrives anymore and the policy does not change any allocation for a whole period.
+func (s *OfflineSimulator) Simulate() (*simulationapi.SimulationResult, error) {
+	s.policy.Initialize()
+	defer s.policy.UnInitialize()
+
+	pending := make([]simulationapi.SimulationJob, len(s.spec.Jobs))
+	copy(pending, s.spec.Jobs)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Time < pending[j].Time
+	})
+
+	var jobs []*simulatedJob
+	active := make(map[info.JobID]*simulatedJob)
+	s.now = 0
+	// lastActivity is the last time a job arrived or ran, or the policy changed an allocation
+	lastActivity := 0.0
+	for len(pending) > 0 || len(active) > 0 {
+		// Admit arrived jobs
+		for len(pending) > 0 && pending[0].Time <= s.now {
+			lastActivity = s.now
+			job, err := s.newSimulatedJob(&pending[0])
+			if err != nil {
+				return nil, err
+			}
+			if _, found := active[job.info.UID]; found {
+				return nil, fmt.Errorf("duplicated job %v", job.info.UID)
+			}
+			jobs = append(jobs, job)
+			active[job.info.UID] = job
+			pending = pending[1:]
+		}
+
+		changed := false
+		if len(active) > 0 {
+			changed = s.schedule(active)
+		}
+
+		// Find the next event
+		next := math.Inf(1)
+		if len(pending) > 0 {
+			next = pending[0].Time
+		}
+		for _, job := range active {
+			next = math.Min(next, s.now+job.timeToFinish())
+		}
+		if changed || !math.IsInf(next, 1) {
+			lastActivity = s.now
+		}
+		// Without jobs running or arriving, periodic cycles only help if the policy changes its mind, which
+		// it may do later, e.g. when a job waits for its turn, so it is given a whole period to do so
+		if math.IsInf(next, 1) && (s.period <= 0 || s.now-lastActivity >= s.period) {
+			return nil, fmt.Errorf("%d jobs can never complete under policy %s at T+%vs",
+				len(active), s.policy.Name(), s.now)
+		}
+		if s.period > 0 && len(active) > 0 {
+			next = math.Min(next, s.now+s.period)
+		}
+
+		// Advance the virtual clock
+		elapsed := next - s.now
+		s.now = next
+		for id, job := range active {
+			if job.advance(elapsed) {
+				job.finish = s.now
+				delete(active, id)
+			}
+		}
+	}
+
+	return s.result(jobs), nil
+}
+
+func (s *OfflineSimulator) newSimulatedJob(spec *simulationapi.SimulationJob) (*simulatedJob, error) {
+	job := spec.Job.DeepCopy()
+	if job.Namespace == "" {
+		job.Namespace = "default"
+	}
+	jobID := info.JobID(fmt.Sprintf("%s/%s", job.Namespace, job.Name))
+	job.CreationTimestamp = metav1.NewTime(s.timestamp(spec.Time))
+	job.Status = []pintav1.PintaJobStatus{
+		{
+			State:              pintav1.Idle,
+			LastTransitionTime: job.CreationTimestamp,
+		},
+	}
+
+	customFields := &JobCustomFields{}
+	err := yaml.Unmarshal([]byte(job.GetAnnotations()["pinta.qed.usc.edu/custom-fields"]), customFields)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse custom fields for job %v: %v", job.Name, err)
+	}
+	if customFields.BatchSize <= 0 || customFields.Iterations <= 0 || len(customFields.Throughput) == 0 {
+		return nil, fmt.Errorf("job %v needs batchSize, iterations and throughput to be simulated", job.Name)
+	}
+
+	jobInfo := info.NewJobInfo(jobID, job)
+	if err := jobInfo.ParseCustomFields(s.policy.JobCustomFieldsType()); err != nil {
+		return nil, fmt.Errorf("cannot parse custom fields for job %v: %v", job.Name, err)
+	}
+
+	return &simulatedJob{
+		info:         jobInfo,
+		customFields: customFields,
+		arrival:      spec.Time,
+		start:        -1,
+	}, nil
+}
+
+// schedule executes the policy on the active jobs and commits the allocations. It returns whether any
+// allocation changed.
+func (s *OfflineSimulator) schedule(active map[info.JobID]*simulatedJob) bool {
+	snapshot := info.NewClusterInfo()
+	for name, node := range s.nodes {
+		snapshot.Nodes[name] = node
+	}
+	for id, job := range active {
+		// Jobs report their progress the same way they would to the scheduler
+		job.info.Progress = &info.JobProgress{
+			CompletedIterations: int(job.completedIterations),
+			ReportTime:          metav1.NewTime(s.timestamp(s.now)),
+		}
+		snapshot.Jobs[id] = job.info
+	}
+
+	ssn := session.OpenSessionWithSnapshot(snapshot, s.jobStates, s.timestamp(s.now))
+	session.ExecutePolicies(ssn, []session.Policy{s.policy})
+
+	changed := false
+	pool := session.NewNodePool(ssn)
+	for _, job := range active {
+		if !pool.AllocateJob(job.info, job.info.NumMasters, job.info.NumReplicas) {
+			klog.Warningf("Policy %s allocated more than the cluster has to job %s at T+%vs",
+				s.policy.Name(), job.info.Name, s.now)
+		}
+		if s.commit(job) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// commit records the allocation as the job status, the same way the scheduler and controller would. It
+// returns whether the allocation changed.
+func (s *OfflineSimulator) commit(job *simulatedJob) bool {
+	jobInfo := job.info
+	lastPintaJobStatus := jobInfo.Job.Status[0]
+	if jobInfo.NumMasters == lastPintaJobStatus.NumMasters && jobInfo.NumReplicas == lastPintaJobStatus.NumReplicas {
+		return false
+	}
+
+	state := lastPintaJobStatus.State
+	if jobInfo.NumReplicas > 0 {
+		state = pintav1.Running
+		if job.start < 0 {
+			job.start = s.now
+		}
+	} else if state == pintav1.Running {
+		state = pintav1.Preempted
+	}
+
+	jobInfo.Job.Status = append([]pintav1.PintaJobStatus{
+		{
+			State:              state,
+			LastTransitionTime: metav1.NewTime(s.timestamp(s.now)),
+			NumMasters:         jobInfo.NumMasters,
+			NumReplicas:        jobInfo.NumReplicas,
+		},
+	}, jobInfo.Job.Status...)
+	return true
+}
+
+func (s *OfflineSimulator) timestamp(relativeTime float64) time.Time {
+	return s.baseTimestamp.Add(time.Duration(relativeTime * 1e9))
+}
+
+func (s *OfflineSimulator) result(jobs []*simulatedJob) *simulationapi.SimulationResult {
+	result := &simulationapi.SimulationResult{
+		Policy: s.policy.Name(),
+		Jobs:   make([]simulationapi.SimulationJobResult, 0, len(jobs)),
+	}
+	if len(jobs) == 0 {
+		return result
+	}
+
+	firstArrival := math.Inf(1)
+	lastFinish := 0.0
+	for _, job := range jobs {
+		jct := job.finish - job.arrival
+		queueingTime := job.start - job.arrival
+		result.Jobs = append(result.Jobs, simulationapi.SimulationJobResult{
+			Name:         job.info.Name,
+			Arrival:      job.arrival,
+			Start:        job.start,
+			Finish:       job.finish,
+			JCT:          jct,
+			QueueingTime: queueingTime,
+		})
+		result.AverageJCT += jct
+		result.AverageQueueingTime += queueingTime
+		firstArrival = math.Min(firstArrival, job.arrival)
+		lastFinish = math.Max(lastFinish, job.finish)
+	}
+	result.AverageJCT /= float64(len(jobs))
+	result.AverageQueueingTime /= float64(len(jobs))
+	result.Makespan = lastFinish - firstArrival
+
+	return result
+}
+
+// rate returns the number of iterations the job completes per second under its current allocation.
+func (job *simulatedJob) rate() float64 {
+	numReplicas := int(job.info.NumReplicas)
+	if numReplicas <= 0 {
+		return 0
+	}
+	throughput := job.customFields.Throughput
+	if numReplicas > len(throughput) {
+		numReplicas = len(throughput)
+	}
+	return throughput[numReplicas-1] / float64(job.customFields.BatchSize)
+}
+
+func (job *simulatedJob) timeToFinish() float64 {
+	rate := job.rate()
+	if rate <= 0 {
+		return math.Inf(1)
+	}
+	return (float64(job.customFields.Iterations) - job.completedIterations) / rate
+}
+
+// advance progresses the job by the given number of seconds, and returns whether the job has completed.
+func (job *simulatedJob) advance(elapsed float64) bool {
+	remaining := float64(job.customFields.Iterations) - job.completedIterations
+	progress := job.rate() * elapsed
+	if progress >= remaining-1e-6 {
+		job.completedIterations = float64(job.customFields.Iterations)
+		return true
+	}
+	job.completedIterations += progress
+	return false
+}
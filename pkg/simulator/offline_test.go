@@ -0,0 +1,181 @@
+package simulator
+
+import (
+	pintav1 "github.com/qed-usc/pinta-scheduler/pkg/apis/pinta/v1"
+	simulationapi "github.com/qed-usc/pinta-scheduler/pkg/apis/simulation"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/equi"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/fcfs"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/policies/timeslice"
+	"github.com/qed-usc/pinta-scheduler/pkg/scheduler/session"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func buildSimulationJob(name string, time float64, customFieldsStr string) simulationapi.SimulationJob {
+	return simulationapi.SimulationJob{
+		Time: time,
+		Job: pintav1.PintaJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Annotations: map[string]string{
+					"pinta.qed.usc.edu/custom-fields": customFieldsStr,
+				},
+			},
+			Spec: pintav1.PintaJobSpec{
+				Type: pintav1.Symmetric,
+			},
+		},
+	}
+}
+
+func buildClusterSpec(numNodes int) *simulationapi.ClusterSpec {
+	return &simulationapi.ClusterSpec{
+		Nodes: []simulationapi.NodeGroupSpec{
+			{
+				Type:  "type1",
+				Count: numNodes,
+				Resources: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("4"),
+					v1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+	}
+}
+
+func TestOfflineSimulator_Simulate(t *testing.T) {
+	customFieldsStr := `
+batchSize: 1
+iterations: 10
+throughput: [1, 2]
+`
+
+	tests := []struct {
+		name     string
+		jobs     []simulationapi.SimulationJob
+		expected *simulationapi.SimulationResult
+	}{
+		{
+			name: "jobs arriving one after another",
+			jobs: []simulationapi.SimulationJob{
+				buildSimulationJob("j1", 0, customFieldsStr),
+				buildSimulationJob("j2", 10, customFieldsStr),
+			},
+			expected: &simulationapi.SimulationResult{
+				Policy:              "equi",
+				Makespan:            15,
+				AverageJCT:          5,
+				AverageQueueingTime: 0,
+				Jobs: []simulationapi.SimulationJobResult{
+					{Name: "j1", Arrival: 0, Start: 0, Finish: 5, JCT: 5, QueueingTime: 0},
+					{Name: "j2", Arrival: 10, Start: 10, Finish: 15, JCT: 5, QueueingTime: 0},
+				},
+			},
+		},
+		{
+			name: "jobs arriving together",
+			jobs: []simulationapi.SimulationJob{
+				buildSimulationJob("j1", 0, customFieldsStr),
+				buildSimulationJob("j2", 0, customFieldsStr),
+			},
+			expected: &simulationapi.SimulationResult{
+				Policy:              "equi",
+				Makespan:            10,
+				AverageJCT:          10,
+				AverageQueueingTime: 0,
+				Jobs: []simulationapi.SimulationJobResult{
+					{Name: "j1", Arrival: 0, Start: 0, Finish: 10, JCT: 10, QueueingTime: 0},
+					{Name: "j2", Arrival: 0, Start: 0, Finish: 10, JCT: 10, QueueingTime: 0},
+				},
+			},
+		},
+	}
+
+	policy, err := equi.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	for _, test := range tests {
+		spec := &simulationapi.SimulatorSpec{Jobs: test.jobs}
+		s := newOfflineSimulator(spec, buildClusterSpec(2), policy, 0)
+
+		result, err := s.Simulate()
+		if err != nil {
+			t.Errorf("%s: simulation failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: \n expected %+v, \n got %+v \n", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestOfflineSimulator_Unschedulable(t *testing.T) {
+	customFieldsStr := `
+numReplicas: 3
+batchSize: 1
+iterations: 10
+throughput: [1, 2, 3]
+`
+
+	policy, err := fcfs.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	// Periodic cycles do not keep the simulation going when nothing can ever change
+	for _, period := range []float64{0, 10} {
+		spec := &simulationapi.SimulatorSpec{Jobs: []simulationapi.SimulationJob{
+			buildSimulationJob("j1", 0, customFieldsStr),
+		}}
+		s := newOfflineSimulator(spec, buildClusterSpec(2), policy, period)
+
+		if _, err := s.Simulate(); err == nil || !strings.Contains(err.Error(), "can never complete") {
+			t.Errorf("Period %v: expected the job to never complete, got error %v", period, err)
+		}
+	}
+}
+
+func TestOfflineSimulator_TimeSlices(t *testing.T) {
+	customFieldsStr := `
+numReplicas: 2
+batchSize: 1
+iterations: 30
+throughput: [1, 2]
+`
+
+	policy, err := timeslice.New(session.Arguments{"quantum": "10"})
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	// Job j2 waits for two periods before its turn, and job j1 for one more slice after that
+	spec := &simulationapi.SimulatorSpec{Jobs: []simulationapi.SimulationJob{
+		buildSimulationJob("j1", 0, customFieldsStr),
+		buildSimulationJob("j2", 0, customFieldsStr),
+	}}
+	s := newOfflineSimulator(spec, buildClusterSpec(2), policy, 5)
+
+	result, err := s.Simulate()
+	if err != nil {
+		t.Fatalf("Simulation failed: %v", err)
+	}
+	expected := &simulationapi.SimulationResult{
+		Policy:              "timeslice",
+		Makespan:            30,
+		AverageJCT:          27.5,
+		AverageQueueingTime: 5,
+		Jobs: []simulationapi.SimulationJobResult{
+			{Name: "j1", Arrival: 0, Start: 0, Finish: 25, JCT: 25, QueueingTime: 0},
+			{Name: "j2", Arrival: 0, Start: 10, Finish: 30, JCT: 30, QueueingTime: 10},
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\n expected %+v, \n got %+v \n", expected, result)
+	}
+}